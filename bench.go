@@ -0,0 +1,152 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"slices"
+	"time"
+
+	"github.com/gdamore/tcell"
+	"gocv.io/x/gocv"
+)
+
+// benchStages are the pipeline stages timed by the benchmark, in order.
+var benchStages = []string{"capture", "resize", "convert", "draw"}
+
+// benchSource produces the frames of a benchmark: a video file, looped, or
+// random noise at 720p, the worst case for conversion.
+type benchSource struct {
+	video *gocv.VideoCapture
+	path  string
+}
+
+func (b *benchSource) read(img *gocv.Mat) error {
+	if b.path == "" {
+		if img.Empty() {
+			*img = gocv.NewMatWithSize(720, 1280, gocv.MatTypeCV8UC3)
+		}
+		gocv.RandN(img, gocv.NewScalar(128, 128, 128, 0), gocv.NewScalar(40, 40, 40, 0))
+		return nil
+	}
+	if b.video == nil || !b.video.Read(img) || img.Empty() {
+		// start over at the end of the file
+		if b.video != nil {
+			b.video.Close()
+		}
+		vc, err := gocv.VideoCaptureFile(b.path)
+		if err != nil {
+			return err
+		}
+		b.video = vc
+		if !vc.Read(img) || img.Empty() {
+			return fmt.Errorf("no frames in %v", b.path)
+		}
+	}
+	return nil
+}
+
+func (b *benchSource) close() {
+	if b.video != nil {
+		b.video.Close()
+	}
+}
+
+// runBench runs the capture, conversion and drawing pipeline headlessly for
+// duration on a cols x rows terminal with the current settings, reading
+// input or synthetic frames, and writes the frame rate, the latency of each
+// stage and the allocations per frame to w.
+func runBench(w io.Writer, input string, cols, rows int, duration time.Duration) error {
+	src := &benchSource{path: input}
+	defer src.close()
+	defer closeGPU()
+
+	sim := tcell.NewSimulationScreen("UTF-8")
+	if err := sim.Init(); err != nil {
+		return err
+	}
+	defer sim.Fini()
+	sim.SetSize(cols, rows+logHeight)
+	overlays = &compositor{defStyle: tcell.StyleDefault}
+
+	img := gocv.NewMat()
+	defer img.Close()
+	small := gocv.NewMat()
+	defer small.Close()
+	padded := gocv.NewMat()
+	defer padded.Close()
+
+	var tiles tiledConverter
+	latency := map[string][]time.Duration{}
+	stage := func(name string, start time.Time) time.Time {
+		now := time.Now()
+		latency[name] = append(latency[name], now.Sub(start))
+		return now
+	}
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	start := time.Now()
+	frames := 0
+	for time.Since(start) < duration {
+		t := time.Now()
+		if err := src.read(&img); err != nil {
+			return err
+		}
+		t = stage("capture", t)
+
+		sx, sy := cellSamples()
+		resized, fw, fh, err := fitFrame(img, &small, &padded, cols, rows, sx, sy, fitMode(frameFit.Load()))
+		if err != nil {
+			return err
+		}
+		t = stage("resize", t)
+
+		f := tiles.convert(resized, fw, fh)
+		releaseImage(resized)
+		t = stage("convert", t)
+
+		overlays.setVideo(videoFrame(f, cols, rows))
+		overlays.draw(sim)
+		sim.Show()
+		stage("draw", t)
+		frames++
+	}
+	elapsed := time.Since(start)
+	runtime.ReadMemStats(&after)
+
+	fmt.Fprintf(w, "%d frames of %dx%d cells in %v: %.1f fps\n", frames, cols, rows, elapsed.Round(time.Millisecond), float64(frames)/elapsed.Seconds())
+	for _, name := range benchStages {
+		d := latency[name]
+		slices.Sort(d)
+		var total time.Duration
+		for _, v := range d {
+			total += v
+		}
+		fmt.Fprintf(w, "%-8s mean %8v  p50 %8v  p95 %8v  max %8v\n", name,
+			(total / time.Duration(max(len(d), 1))).Round(time.Microsecond),
+			benchPercentile(d, 0.5), benchPercentile(d, 0.95), benchPercentile(d, 1))
+	}
+	n := uint64(max(frames, 1))
+	fmt.Fprintf(w, "allocations: %d per frame, %d bytes per frame, %d GCs\n",
+		(after.Mallocs-before.Mallocs)/n, (after.TotalAlloc-before.TotalAlloc)/n, after.NumGC-before.NumGC)
+	return nil
+}
+
+// benchPercentile returns the p-th percentile of sorted latencies.
+func benchPercentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	i := min(int(p*float64(len(sorted)-1)+0.5), len(sorted)-1)
+	return sorted[i].Round(time.Microsecond)
+}
+
+// parseBenchSize parses a terminal size such as "160x48".
+func parseBenchSize(s string) (int, int, error) {
+	var cols, rows int
+	if _, err := fmt.Sscanf(s, "%dx%d", &cols, &rows); err != nil || cols <= 0 || rows <= 0 {
+		return 0, 0, fmt.Errorf("invalid size %q, want COLSxROWS", s)
+	}
+	return cols, rows, nil
+}
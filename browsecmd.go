@@ -0,0 +1,313 @@
+//go:build !minimal
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell"
+)
+
+const (
+	// browseListWidth is the width of the recording list in the browser.
+	browseListWidth = 44
+	seekStep        = 5 * time.Second
+	maxPlaybackRate = 8
+	minPlaybackRate = 0.25
+)
+
+func init() {
+	subcommands["browse"] = subcommand{run: runBrowse, summary: "browse and replay .cast recordings in a directory", failure: "Error browsing"}
+}
+
+// runBrowse implements the browse subcommand, a terminal UI listing the
+// recordings in a directory with a preview of their first frame, which
+// replays the selected one.
+func runBrowse(args []string) error {
+	fs := flag.NewFlagSet("browse", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s browse [dir]\n", filepath.Base(os.Args[0]))
+		fs.PrintDefaults()
+	}
+	dirs, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	dir := "."
+	switch len(dirs) {
+	case 0:
+	case 1:
+		dir = dirs[0]
+	default:
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	recordings, err := findRecordings(dir)
+	if err != nil {
+		return err
+	}
+	if len(recordings) == 0 {
+		return fmt.Errorf("no .cast recordings in %v", dir)
+	}
+
+	s, err := tcell.NewScreen()
+	if err != nil {
+		return err
+	}
+	if err := s.Init(); err != nil {
+		return err
+	}
+	defer s.Fini()
+	if colors == colorAuto {
+		colors, _ = detectColorDepth(s)
+	}
+
+	events := make(chan tcell.Event)
+	go func() {
+		for {
+			ev := s.PollEvent()
+			if ev == nil {
+				return
+			}
+			events <- ev
+		}
+	}()
+
+	b := &browser{s: s, recordings: recordings, events: events}
+	b.run()
+	return nil
+}
+
+// findRecordings summarizes the .cast files in dir, newest first. Files
+// that cannot be read are skipped.
+func findRecordings(dir string) ([]*castSummary, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var recordings []*castSummary
+	modified := map[*castSummary]time.Time{}
+	for _, entry := range entries {
+		if entry.IsDir() || strings.ToLower(filepath.Ext(entry.Name())) != ".cast" {
+			continue
+		}
+		rec, err := readCastSummary(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			continue
+		}
+		if info, err := entry.Info(); err == nil {
+			modified[rec] = info.ModTime()
+		}
+		recordings = append(recordings, rec)
+	}
+	sort.SliceStable(recordings, func(i, j int) bool { return modified[recordings[i]].After(modified[recordings[j]]) })
+	return recordings, nil
+}
+
+// browser is the state of the browse UI.
+type browser struct {
+	s          tcell.Screen
+	recordings []*castSummary
+	events     <-chan tcell.Event
+	selected   int
+	// problem is shown in place of the key help until the next key.
+	problem string
+}
+
+// run shows the list until the user quits.
+func (b *browser) run() {
+	for {
+		b.drawList()
+		ev := <-b.events
+		key, ok := ev.(*tcell.EventKey)
+		if !ok {
+			continue
+		}
+		b.problem = ""
+		switch {
+		case key.Key() == tcell.KeyUp:
+			b.selected = max(b.selected-1, 0)
+		case key.Key() == tcell.KeyDown:
+			b.selected = min(b.selected+1, len(b.recordings)-1)
+		case key.Key() == tcell.KeyEnter:
+			// the events are only loaded for playback, since listing every
+			// recording in full is slow for large captures
+			rec, err := readCast(b.recordings[b.selected].path)
+			if err != nil {
+				b.problem = err.Error()
+				continue
+			}
+			b.play(rec)
+		case key.Key() == tcell.KeyEscape, key.Key() == tcell.KeyCtrlC, key.Key() == tcell.KeyRune && key.Rune() == 'q':
+			return
+		}
+	}
+}
+
+// drawText writes text at x, y, clipped to width cells.
+func drawText(s tcell.Screen, x, y, width int, text string, style tcell.Style) {
+	for i, r := range []rune(text) {
+		if i >= width {
+			return
+		}
+		s.SetContent(x+i, y, r, nil, style)
+	}
+}
+
+// drawFrame draws f scaled into the given screen area.
+func drawFrame(s tcell.Screen, f *frame, x0, y0, width, height int) {
+	if width <= 0 || height <= 0 {
+		return
+	}
+	f = scaleFrame(f, width, height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			c := f.at(x, y)
+			style := tcell.StyleDefault
+			if c.color.A != 0 {
+				style = foregroundStyles.style(c.color, style, colors)
+			}
+			s.SetContent(x0+x, y0+y, c.r, nil, style)
+		}
+	}
+}
+
+func formatSize(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", n)
+}
+
+func formatPosition(d time.Duration) string {
+	return fmt.Sprintf("%02d:%02d", int(d.Minutes()), int(d.Seconds())%60)
+}
+
+// drawList draws the recording list with a preview of the selected one.
+func (b *browser) drawList() {
+	b.s.Clear()
+	width, height := b.s.Size()
+	listWidth := min(browseListWidth, width/2)
+
+	for i, rec := range b.recordings {
+		if i >= height-1 {
+			break
+		}
+		style := tcell.StyleDefault
+		if i == b.selected {
+			style = style.Reverse(true)
+		}
+		info := fmt.Sprintf(" %v %8v", formatPosition(rec.length), formatSize(rec.size))
+		name := filepath.Base(rec.path)
+		nameWidth := max(listWidth-len(info), 0)
+		if len(name) > nameWidth {
+			name = name[:nameWidth]
+		}
+		drawText(b.s, 0, i, listWidth, fmt.Sprintf("%-*s%s", nameWidth, name, info), style)
+	}
+	if b.problem != "" {
+		drawText(b.s, 0, height-1, width, b.problem, tcell.StyleDefault.Foreground(palette.highlight))
+	} else {
+		drawText(b.s, 0, height-1, width, "up/down select  enter play  q quit", tcell.StyleDefault.Foreground(palette.message))
+	}
+
+	drawFrame(b.s, b.recordings[b.selected].preview, listWidth+1, 0, width-listWidth-1, height-1)
+	b.s.Show()
+}
+
+// play replays a recording with pause, seek and speed controls until the
+// user goes back to the list.
+func (b *browser) play(rec *castRecording) {
+	ticker := time.NewTicker(time.Second / 30)
+	defer ticker.Stop()
+
+	var position, markIn, markOut time.Duration
+	rate := 1.0
+	message := ""
+	paused := false
+	last := time.Now()
+	for {
+		now := time.Now()
+		if !paused {
+			position += time.Duration(float64(now.Sub(last)) * rate)
+		}
+		last = now
+		if position >= rec.duration() {
+			position, paused = rec.duration(), true
+		}
+
+		b.s.Clear()
+		width, height := b.s.Size()
+		drawFrame(b.s, rec.render(position), 0, 0, width, height-1)
+		state := "playing"
+		if paused {
+			state = "paused"
+		}
+		status := fmt.Sprintf("%v  %v / %v  %gx", state, formatPosition(position), formatPosition(rec.duration()), rate)
+		if markIn > 0 || markOut > 0 {
+			status += fmt.Sprintf("  in %v out %v", formatPosition(markIn), formatPosition(markOut))
+		}
+		if message != "" {
+			drawText(b.s, 0, height-1, width, status+"  "+message, tcell.StyleDefault.Foreground(palette.highlight))
+		} else {
+			drawText(b.s, 0, height-1, width, status+"  space pause  left/right seek  +/- speed  i/o mark  x trim  q back", tcell.StyleDefault.Foreground(palette.message))
+		}
+		b.s.Show()
+
+		select {
+		case <-ticker.C:
+		case ev := <-b.events:
+			key, ok := ev.(*tcell.EventKey)
+			if !ok {
+				continue
+			}
+			message = ""
+			switch {
+			case key.Key() == tcell.KeyRune && key.Rune() == ' ':
+				if paused && position >= rec.duration() {
+					position = 0
+				}
+				paused = !paused
+			case key.Key() == tcell.KeyLeft:
+				position = max(position-seekStep, 0)
+			case key.Key() == tcell.KeyRight:
+				position = min(position+seekStep, rec.duration())
+			case key.Key() == tcell.KeyRune && key.Rune() == '+':
+				rate = min(rate*2, maxPlaybackRate)
+			case key.Key() == tcell.KeyRune && key.Rune() == '-':
+				rate = max(rate/2, minPlaybackRate)
+			case key.Key() == tcell.KeyRune && key.Rune() == 'i':
+				markIn = position
+			case key.Key() == tcell.KeyRune && key.Rune() == 'o':
+				markOut = position
+			case key.Key() == tcell.KeyRune && key.Rune() == 'x':
+				message = trimMarked(rec, markIn, markOut)
+			case key.Key() == tcell.KeyEscape, key.Key() == tcell.KeyRune && key.Rune() == 'q':
+				return
+			}
+		}
+	}
+}
+
+// trimMarked writes the part of rec between the in and out markers next to
+// it, returning what to tell the user.
+func trimMarked(rec *castRecording, markIn, markOut time.Duration) string {
+	if markOut <= markIn {
+		return "set the out marker after the in marker"
+	}
+	output := strings.TrimSuffix(rec.path, filepath.Ext(rec.path)) + "-trim.cast"
+	if err := trimCast(rec.path, output, markIn, markOut); err != nil {
+		return err.Error()
+	}
+	return "saved " + filepath.Base(output)
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"image"
+	"sync"
+	"sync/atomic"
+
+	"gocv.io/x/gocv"
+)
+
+// rgbaBuffers and grayBuffers recycle the images handed from the capture
+// goroutine to the viewer, which returns them with releaseImage once a
+// frame is converted, so a steady stream of frames of one size allocates
+// no new images.
+var rgbaBuffers, grayBuffers sync.Pool
+
+// rgbaBuffer returns an image of the given size, reusing a released one
+// when its size matches.
+func rgbaBuffer(width, height int) *image.RGBA {
+	if img, ok := rgbaBuffers.Get().(*image.RGBA); ok && img.Rect.Dx() == width && img.Rect.Dy() == height {
+		return img
+	}
+	return image.NewRGBA(image.Rect(0, 0, width, height))
+}
+
+// grayBuffer is rgbaBuffer for grayscale images.
+func grayBuffer(width, height int) *image.Gray {
+	if img, ok := grayBuffers.Get().(*image.Gray); ok && img.Rect.Dx() == width && img.Rect.Dy() == height {
+		return img
+	}
+	return image.NewGray(image.Rect(0, 0, width, height))
+}
+
+// releaseImage hands an image back for reuse. It must not be used after.
+func releaseImage(img image.Image) {
+	switch img := img.(type) {
+	case *image.RGBA:
+		rgbaBuffers.Put(img)
+	case *image.Gray:
+		grayBuffers.Put(img)
+	}
+}
+
+// matToImage converts a BGR or grayscale Mat to an image in a recycled
+// buffer. Other Mat types go through gocv's own conversion.
+func matToImage(m gocv.Mat) (image.Image, error) {
+	if (m.Type() != gocv.MatTypeCV8UC3 && m.Type() != gocv.MatTypeCV8UC1) || !m.IsContinuous() {
+		return m.ToImage()
+	}
+	data, err := m.DataPtrUint8()
+	if err != nil {
+		return nil, err
+	}
+	if m.Type() == gocv.MatTypeCV8UC1 {
+		img := grayBuffer(m.Cols(), m.Rows())
+		copy(img.Pix, data)
+		return img, nil
+	}
+	img := rgbaBuffer(m.Cols(), m.Rows())
+	for i, j := 0, 0; i+2 < len(data); i, j = i+3, j+4 {
+		img.Pix[j], img.Pix[j+1], img.Pix[j+2], img.Pix[j+3] = data[i+2], data[i+1], data[i], 255
+	}
+	return img, nil
+}
+
+// grayFrames is set by the viewer while nothing shows the frame colors, so
+// the capture goroutine converts frames to grayscale in OpenCV and the
+// conversion reads one byte per pixel. The palettes and tints only need
+// the brightness and work either way.
+var grayFrames atomic.Bool
+
+// frameImage converts a filtered Mat to the image handed to the viewer,
+// in grayscale if grayFrames is set. m may be converted in place.
+func frameImage(m *gocv.Mat) (image.Image, error) {
+	if grayFrames.Load() && m.Channels() == 3 {
+		gocv.CvtColor(*m, m, gocv.ColorBGRToGray)
+	}
+	return matToImage(*m)
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"gocv.io/x/gocv"
+)
+
+const (
+	// maxReadFailures is the number of consecutive failed reads after which
+	// the camera is reopened.
+	maxReadFailures = 30
+	readRetryDelay  = 50 * time.Millisecond
+	// cameraRetryDelay is the wait between attempts to open the camera.
+	cameraRetryDelay = 2 * time.Second
+)
+
+// openCamera opens the capture device and reads the settings that depend
+// on it.
+func openCamera() (*gocv.VideoCapture, error) {
+	vc, err := gocv.VideoCaptureDevice(deviceID)
+	if err != nil {
+		return nil, err
+	}
+	if fps := vc.Get(gocv.VideoCaptureFPS); fps > 0 {
+		captureFPS = fps
+	}
+	if rotation == rotationAuto {
+		frameRotation.Store(int32(detectRotation(vc)))
+	}
+	readCameraProperties(vc)
+	return vc, nil
+}
+
+// cameraControl is a capture device property adjustable from the settings
+// menu.
+type cameraControl struct {
+	label string
+	prop  gocv.VideoCaptureProperties
+}
+
+var cameraControls = []cameraControl{
+	{"Camera brightness", gocv.VideoCaptureBrightness},
+	{"Camera contrast", gocv.VideoCaptureContrast},
+	{"Camera saturation", gocv.VideoCaptureSaturation},
+	{"Camera exposure", gocv.VideoCaptureExposure},
+	{"Camera gain", gocv.VideoCaptureGain},
+}
+
+// cameraProperties passes property changes from the UI to the capture
+// goroutine, which owns the device, and the values it reads back to the UI.
+var cameraProperties = struct {
+	mu      sync.Mutex
+	values  map[gocv.VideoCaptureProperties]float64
+	pending map[gocv.VideoCaptureProperties]float64
+}{
+	values:  map[gocv.VideoCaptureProperties]float64{},
+	pending: map[gocv.VideoCaptureProperties]float64{},
+}
+
+// readCameraProperties records the current value of every camera control.
+func readCameraProperties(vc *gocv.VideoCapture) {
+	cameraProperties.mu.Lock()
+	defer cameraProperties.mu.Unlock()
+	for _, c := range cameraControls {
+		cameraProperties.values[c.prop] = vc.Get(c.prop)
+	}
+}
+
+// applyCameraProperties sets the properties changed since the last call,
+// recording the values the device actually accepted.
+func applyCameraProperties(vc *gocv.VideoCapture) {
+	cameraProperties.mu.Lock()
+	defer cameraProperties.mu.Unlock()
+	for prop, v := range cameraProperties.pending {
+		vc.Set(prop, v)
+		cameraProperties.values[prop] = vc.Get(prop)
+		delete(cameraProperties.pending, prop)
+	}
+}
+
+// changeCameraProperty asks the capture goroutine to move a property by
+// steps. Backends report either normalized values or raw driver ranges, so
+// the step is small for values within [0, 1] and relative otherwise.
+func changeCameraProperty(prop gocv.VideoCaptureProperties, steps int) {
+	cameraProperties.mu.Lock()
+	defer cameraProperties.mu.Unlock()
+	v, ok := cameraProperties.values[prop]
+	if !ok {
+		return
+	}
+	if pending, ok := cameraProperties.pending[prop]; ok {
+		v = pending
+	}
+	step := 0.05
+	if math.Abs(v) > 1 {
+		step = max(math.Round(math.Abs(v)*0.1), 1)
+	}
+	cameraProperties.pending[prop] = v + float64(steps)*step
+}
+
+// cameraPropertyReadout returns the value a property is being set to, or
+// the last value read for it.
+func cameraPropertyReadout(prop gocv.VideoCaptureProperties) string {
+	cameraProperties.mu.Lock()
+	defer cameraProperties.mu.Unlock()
+	v, ok := cameraProperties.pending[prop]
+	if !ok {
+		v, ok = cameraProperties.values[prop]
+	}
+	if !ok {
+		return "n/a"
+	}
+	return fmt.Sprintf("%.2f", v)
+}
@@ -0,0 +1,292 @@
+//go:build !minimal
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// castEvent is an output event of an asciinema recording.
+type castEvent struct {
+	at   time.Duration
+	data string
+}
+
+// castRecording is an asciinema v2 recording loaded for playback.
+type castRecording struct {
+	path          string
+	size          int64
+	width, height int
+	events        []castEvent
+}
+
+// castHeader is the first line of an asciinema v2 file.
+type castHeader struct {
+	Version int `json:"version"`
+	Width   int `json:"width"`
+	Height  int `json:"height"`
+}
+
+func parseCastHeader(path string, line []byte) (castHeader, error) {
+	var header castHeader
+	if err := json.Unmarshal(line, &header); err != nil || header.Version != 2 {
+		return header, fmt.Errorf("%v is not an asciinema v2 recording", path)
+	}
+	return header, nil
+}
+
+// parseCastEvent parses an event line, returning its time, kind and data.
+func parseCastEvent(path string, line []byte) (time.Duration, string, string, error) {
+	var ev []interface{}
+	if err := json.Unmarshal(line, &ev); err != nil || len(ev) < 3 {
+		return 0, "", "", fmt.Errorf("malformed event in %v", path)
+	}
+	seconds, ok1 := ev[0].(float64)
+	kind, ok2 := ev[1].(string)
+	data, ok3 := ev[2].(string)
+	if !ok1 || !ok2 || !ok3 {
+		return 0, "", "", fmt.Errorf("malformed event in %v", path)
+	}
+	return time.Duration(seconds * float64(time.Second)), kind, data, nil
+}
+
+// readCast loads the output events of an asciinema v2 file.
+func readCast(path string) (*castRecording, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 1<<20), 64<<20)
+	if !scanner.Scan() {
+		return nil, fmt.Errorf("%v is empty", path)
+	}
+	header, err := parseCastHeader(path, scanner.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	rec := &castRecording{path: path, size: info.Size(), width: header.Width, height: header.Height}
+	for scanner.Scan() {
+		at, kind, data, err := parseCastEvent(path, scanner.Bytes())
+		if err != nil {
+			return nil, err
+		}
+		if kind == "o" {
+			rec.events = append(rec.events, castEvent{at, data})
+		}
+	}
+	return rec, scanner.Err()
+}
+
+// castSummary is what a list of recordings shows of one, read without
+// loading all of its events.
+type castSummary struct {
+	path    string
+	size    int64
+	length  time.Duration
+	preview *frame
+}
+
+// readCastSummary reads the header, the first output event and the time
+// of the last event of an asciinema v2 file.
+func readCastSummary(path string) (*castSummary, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 1<<20), 64<<20)
+	if !scanner.Scan() {
+		return nil, fmt.Errorf("%v is empty", path)
+	}
+	header, err := parseCastHeader(path, scanner.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	sum := &castSummary{path: path, size: info.Size()}
+	screen := newANSIScreen(header.Width, header.Height)
+	for scanner.Scan() {
+		_, kind, data, err := parseCastEvent(path, scanner.Bytes())
+		if err != nil {
+			return nil, err
+		}
+		if kind == "o" {
+			screen.write(data)
+			break
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	sum.preview = screen.f
+
+	line, err := lastLine(file, info.Size())
+	if err != nil {
+		return nil, err
+	}
+	if len(line) > 0 {
+		if at, _, _, err := parseCastEvent(path, line); err == nil {
+			sum.length = at
+		}
+	}
+	return sum, nil
+}
+
+// lastLine returns the last non-empty line of a file of the given size,
+// reading backwards from the end.
+func lastLine(file *os.File, size int64) ([]byte, error) {
+	const chunk = 64 << 10
+	var tail []byte
+	for end := size; end > 0; {
+		start := max(end-chunk, 0)
+		buf := make([]byte, end-start)
+		if _, err := file.ReadAt(buf, start); err != nil {
+			return nil, err
+		}
+		tail = append(buf, tail...)
+		trimmed := bytes.TrimRight(tail, "\r\n")
+		if i := bytes.LastIndexByte(trimmed, '\n'); i >= 0 {
+			return trimmed[i+1:], nil
+		}
+		end = start
+	}
+	return bytes.TrimRight(tail, "\r\n"), nil
+}
+
+// duration returns the time of the last event.
+func (r *castRecording) duration() time.Duration {
+	if len(r.events) == 0 {
+		return 0
+	}
+	return r.events[len(r.events)-1].at
+}
+
+// render returns the screen as it was at the given time. Replay starts
+// from the last full redraw, which is every frame in recordings written by
+// the converter, so seeking anywhere is cheap.
+func (r *castRecording) render(at time.Duration) *frame {
+	screen := newANSIScreen(r.width, r.height)
+	last := sort.Search(len(r.events), func(i int) bool { return r.events[i].at > at }) - 1
+	if last < 0 {
+		return screen.f
+	}
+	first := last
+	for first > 0 && !strings.HasPrefix(r.events[first].data, "\x1b[H") {
+		first--
+	}
+	for _, ev := range r.events[first : last+1] {
+		screen.write(ev.data)
+	}
+	return screen.f
+}
+
+// ansiScreen is a minimal terminal emulator understanding the output the
+// exporters produce: text, CR/LF, cursor positioning, clearing and SGR
+// colors. Cells without a color set have a zero alpha.
+type ansiScreen struct {
+	f    *frame
+	x, y int
+	fg   color.RGBA
+}
+
+func newANSIScreen(width, height int) *ansiScreen {
+	a := &ansiScreen{f: newFrame(width, height)}
+	a.clear()
+	return a
+}
+
+func (a *ansiScreen) clear() {
+	for i := range a.f.cells {
+		a.f.cells[i] = cell{r: ' '}
+	}
+}
+
+// write interprets a chunk of terminal output.
+func (a *ansiScreen) write(data string) {
+	runes := []rune(data)
+	for i := 0; i < len(runes); i++ {
+		switch r := runes[i]; {
+		case r == '\r':
+			a.x = 0
+		case r == '\n':
+			a.y++
+		case r == 0x1b && i+1 < len(runes) && runes[i+1] == '[':
+			end := i + 2
+			for end < len(runes) && (runes[end] < 0x40 || runes[end] > 0x7e) {
+				end++
+			}
+			if end < len(runes) {
+				a.csi(string(runes[i+2:end]), runes[end])
+			}
+			i = end
+		case r == 0x1b && i+1 < len(runes) && runes[i+1] == ']':
+			// skip OSC sequences up to BEL or ST
+			for i < len(runes) && runes[i] != 0x07 && !(runes[i] == '\\' && runes[i-1] == 0x1b) {
+				i++
+			}
+		case r < 0x20:
+		default:
+			if a.x < a.f.width && a.y < a.f.height {
+				a.f.set(a.x, a.y, cell{r: r, color: a.fg})
+			}
+			a.x++
+		}
+	}
+}
+
+// csi handles a control sequence with its parameters and final byte.
+func (a *ansiScreen) csi(params string, final rune) {
+	var args []int
+	for _, p := range strings.Split(params, ";") {
+		n, _ := strconv.Atoi(p)
+		args = append(args, n)
+	}
+	arg := func(i, def int) int {
+		if i < len(args) && args[i] > 0 {
+			return args[i]
+		}
+		return def
+	}
+
+	switch final {
+	case 'H', 'f':
+		a.y, a.x = arg(0, 1)-1, arg(1, 1)-1
+	case 'J':
+		if arg(0, 0) == 2 {
+			a.clear()
+		}
+	case 'm':
+		for i := 0; i < len(args); i++ {
+			switch {
+			case args[i] == 0 || args[i] == 39:
+				a.fg = color.RGBA{}
+			case args[i] == 38 && i+4 < len(args) && args[i+1] == 2:
+				a.fg = color.RGBA{uint8(args[i+2]), uint8(args[i+3]), uint8(args[i+4]), 255}
+				i += 4
+			}
+		}
+	}
+}
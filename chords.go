@@ -0,0 +1,41 @@
+package main
+
+import (
+	"github.com/gdamore/tcell"
+)
+
+// leaderKey starts a chord: the key pressed after it selects the event from
+// chordBindings, which keeps single keys free as the number of features
+// grows.
+const leaderKey = ' '
+
+// chordBindings maps the key following the leader key to an event.
+var chordBindings = map[rune]event{
+	'r': resetAdjustments,
+	't': tutorialStart,
+}
+
+// chordParser tracks whether the leader key has been pressed.
+type chordParser struct {
+	pending bool
+}
+
+// feed handles a key event. It reports whether the key was part of a
+// chord and, if so, the event to send. Any key other than a bound rune
+// cancels a pending chord.
+func (c *chordParser) feed(ev *tcell.EventKey) (bool, event) {
+	if c.pending {
+		c.pending = false
+		if ev.Key() == tcell.KeyRune {
+			if bound, ok := chordBindings[ev.Rune()]; ok {
+				return true, bound
+			}
+		}
+		return true, chordUnbound
+	}
+	if ev.Key() == tcell.KeyRune && ev.Rune() == leaderKey {
+		c.pending = true
+		return true, chordPending
+	}
+	return false, 0
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"image"
+
+	"gocv.io/x/gocv"
+)
+
+const (
+	// claheClipLimit limits how much CLAHE may amplify contrast, which
+	// keeps it from boosting noise in flat areas.
+	claheClipLimit = 2
+	// claheTiles is the number of tiles per side CLAHE equalizes separately.
+	claheTiles = 8
+)
+
+// claheFilter equalizes the lightness histogram in tiles (contrast limited
+// adaptive histogram equalization), so poorly lit, low-contrast scenes use
+// the full glyph ramp. Colors are kept by working on the L channel of Lab.
+type claheFilter struct{}
+
+func (claheFilter) name() string { return "clahe" }
+
+func (claheFilter) apply(img *gocv.Mat) {
+	if img.Channels() != 3 {
+		return
+	}
+	lab := gocv.NewMat()
+	defer lab.Close()
+	gocv.CvtColor(*img, &lab, gocv.ColorBGRToLab)
+
+	channels := gocv.Split(lab)
+	defer func() {
+		for _, c := range channels {
+			c.Close()
+		}
+	}()
+
+	clahe := gocv.NewCLAHEWithParams(claheClipLimit, image.Point{X: claheTiles, Y: claheTiles})
+	defer clahe.Close()
+	clahe.Apply(channels[0], &channels[0])
+
+	gocv.Merge(channels, &lab)
+	gocv.CvtColor(lab, img, gocv.ColorLabToBGR)
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"time"
+)
+
+const (
+	saturationStep = 0.1
+	maxSaturation  = 3
+
+	temperatureStep = 0.05
+	// temperatureStrength is how much red and blue are scaled at full
+	// warm or cool temperature.
+	temperatureStrength = 0.3
+
+	hueStep = 15
+	// hueCycleSpeed is how fast the hue rotates in cycling mode, in degrees
+	// per second.
+	hueCycleSpeed = 30
+)
+
+// saturation scales how far colors are from gray; 1 leaves them unchanged
+// and 0 removes all color.
+var saturation float32 = 1
+
+// changeSaturation moves the saturation by steps.
+func changeSaturation(steps int) {
+	saturation = min(max(saturation+float32(steps)*saturationStep, 0), maxSaturation)
+}
+
+func saturationReadout() string {
+	return fmt.Sprintf("Saturation: %.1f", saturation)
+}
+
+func clampChannel(v float32) uint8 {
+	return uint8(min(max(v, 0), 255) + 0.5)
+}
+
+// adjustSaturation pushes a color away from or towards its gray level.
+func adjustSaturation(c color.RGBA) color.RGBA {
+	if saturation == 1 {
+		return c
+	}
+	r, g, b := float32(c.R), float32(c.G), float32(c.B)
+	y := 0.299*r + 0.587*g + 0.114*b
+	return color.RGBA{
+		R: clampChannel(y + (r-y)*saturation),
+		G: clampChannel(y + (g-y)*saturation),
+		B: clampChannel(y + (b-y)*saturation),
+		A: c.A,
+	}
+}
+
+// temperature shifts colors towards warm (positive) or cool (negative),
+// in [-1, 1].
+var temperature float32 = 0
+
+// changeTemperature moves the temperature by steps.
+func changeTemperature(steps int) {
+	temperature = min(max(temperature+float32(steps)*temperatureStep, -1), 1)
+}
+
+func temperatureReadout() string {
+	return fmt.Sprintf("Temperature: %+.2f", temperature)
+}
+
+// adjustTemperature scales red and blue in opposite directions, like a
+// white balance correction.
+func adjustTemperature(c color.RGBA) color.RGBA {
+	if temperature == 0 {
+		return c
+	}
+	return color.RGBA{
+		R: clampChannel(float32(c.R) * (1 + temperature*temperatureStrength)),
+		G: c.G,
+		B: clampChannel(float32(c.B) * (1 - temperature*temperatureStrength)),
+		A: c.A,
+	}
+}
+
+var (
+	// hueShift rotates colors around the hue circle, in degrees.
+	hueShift float64 = 0
+	// hueCycleStart is when continuous hue rotation was turned on, or the
+	// zero time when it is off.
+	hueCycleStart time.Time
+)
+
+// changeHue rotates the hue by steps.
+func changeHue(steps int) {
+	hueShift = math.Mod(hueShift+float64(steps)*hueStep+360, 360)
+}
+
+// toggleHueCycle starts or stops continuous hue rotation, keeping the
+// current rotation when stopping.
+func toggleHueCycle() {
+	if hueCycleStart.IsZero() {
+		hueCycleStart = time.Now()
+	} else {
+		hueShift = currentHue()
+		hueCycleStart = time.Time{}
+	}
+}
+
+// currentHue returns the hue rotation to apply right now.
+func currentHue() float64 {
+	if hueCycleStart.IsZero() {
+		return hueShift
+	}
+	return math.Mod(hueShift+time.Since(hueCycleStart).Seconds()*hueCycleSpeed, 360)
+}
+
+func hueReadout() string {
+	if !hueCycleStart.IsZero() {
+		return fmt.Sprintf("Hue: cycling from %.0f deg", hueShift)
+	}
+	return fmt.Sprintf("Hue: %.0f deg", hueShift)
+}
+
+// rgbToHSV converts 8-bit RGB to hue in degrees and saturation/value in [0, 1].
+func rgbToHSV(c color.RGBA) (h, s, v float64) {
+	r, g, b := float64(c.R)/255, float64(c.G)/255, float64(c.B)/255
+	maxc, minc := max(r, g, b), min(r, g, b)
+	v = maxc
+	d := maxc - minc
+	if maxc > 0 {
+		s = d / maxc
+	}
+	if d == 0 {
+		return 0, s, v
+	}
+	switch maxc {
+	case r:
+		h = math.Mod((g-b)/d, 6)
+	case g:
+		h = (b-r)/d + 2
+	default:
+		h = (r-g)/d + 4
+	}
+	h *= 60
+	if h < 0 {
+		h += 360
+	}
+	return h, s, v
+}
+
+// hsvToRGB converts hue in degrees and saturation/value in [0, 1] to 8-bit RGB.
+func hsvToRGB(h, s, v float64, a uint8) color.RGBA {
+	c := v * s
+	x := c * (1 - math.Abs(math.Mod(h/60, 2)-1))
+	m := v - c
+
+	var r, g, b float64
+	switch {
+	case h < 60:
+		r, g, b = c, x, 0
+	case h < 120:
+		r, g, b = x, c, 0
+	case h < 180:
+		r, g, b = 0, c, x
+	case h < 240:
+		r, g, b = 0, x, c
+	case h < 300:
+		r, g, b = x, 0, c
+	default:
+		r, g, b = c, 0, x
+	}
+	return color.RGBA{
+		R: uint8((r+m)*255 + 0.5),
+		G: uint8((g+m)*255 + 0.5),
+		B: uint8((b+m)*255 + 0.5),
+		A: a,
+	}
+}
+
+// rotateHue shifts a color around the hue circle in HSV space.
+func rotateHue(c color.RGBA, degrees float64) color.RGBA {
+	if degrees == 0 {
+		return c
+	}
+	h, s, v := rgbToHSV(c)
+	return hsvToRGB(math.Mod(h+degrees, 360), s, v, c.A)
+}
+
+// adjustColor applies the color adjustments to a cell color before it is
+// mapped to terminal colors.
+func adjustColor(c color.RGBA) color.RGBA {
+	return rotateHue(adjustSaturation(adjustTemperature(c)), currentHue())
+}
+
+// resetAdjustmentSettings restores every image adjustment and filter to
+// its default.
+func resetAdjustmentSettings() {
+	brightnessOffset, contrastGain = 0, 1
+	autoExposure = false
+	saturation = 1
+	temperature = 0
+	hueShift, hueCycleStart = 0, time.Time{}
+	thresholdEnabled, thresholdLevel = false, 0.5
+	filters = nil
+	posterizeLevels = 0
+	resetImageFilters()
+	sharpenAmount = 0
+	denoiseAmount = 0
+	flicker = flickerOff
+	setOnionSkin(0)
+	setLongExposure(longExposureOff)
+	setStack(0)
+}
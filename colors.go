@@ -0,0 +1,192 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"os"
+
+	"github.com/gdamore/tcell"
+)
+
+// colorDepth is the color capability frames are quantized to when drawn.
+type colorDepth int
+
+const (
+	colorAuto colorDepth = iota
+	colorTrue
+	color256
+	color16
+)
+
+var colorDepthNames = []string{"auto", "truecolor", "256", "16"}
+
+func (d colorDepth) String() string {
+	return colorDepthNames[d]
+}
+
+// Set implements flag.Value.
+func (d *colorDepth) Set(name string) error {
+	for i, n := range colorDepthNames {
+		if n == name {
+			*d = colorDepth(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown color mode %q", name)
+}
+
+// detectColorDepth picks the best color depth the terminal supports and
+// explains the choice. tcell only emits RGB escapes when the terminfo entry
+// has RGB capabilities, so COLORTERM alone is not enough for truecolor.
+func detectColorDepth(s tcell.Screen) (colorDepth, string) {
+	term := os.Getenv("TERM")
+	colorterm := os.Getenv("COLORTERM")
+
+	if s.Colors() >= 1<<24 {
+		return colorTrue, fmt.Sprintf("terminfo for %v supports RGB", term)
+	}
+
+	var reason string
+	switch {
+	case os.Getenv("TCELL_TRUECOLOR") == "disable":
+		reason = "TCELL_TRUECOLOR=disable"
+	case colorterm == "truecolor" || colorterm == "24bit":
+		reason = fmt.Sprintf("COLORTERM=%v but terminfo for %v has no RGB support", colorterm, term)
+	case os.Getenv("TMUX") != "":
+		reason = "running inside tmux without RGB support"
+	default:
+		reason = fmt.Sprintf("terminfo for %v has no RGB support", term)
+	}
+
+	if s.Colors() >= 256 {
+		return color256, reason
+	}
+	return color16, fmt.Sprintf("%v, %d colors", reason, s.Colors())
+}
+
+// cubeLevels are the channel intensities of the xterm 6x6x6 color cube.
+var cubeLevels = [6]int32{0, 95, 135, 175, 215, 255}
+
+// palette16 holds the xterm default values of the basic 16 colors.
+var palette16 = [16]color.RGBA{
+	{0, 0, 0, 255}, {128, 0, 0, 255}, {0, 128, 0, 255}, {128, 128, 0, 255},
+	{0, 0, 128, 255}, {128, 0, 128, 255}, {0, 128, 128, 255}, {192, 192, 192, 255},
+	{128, 128, 128, 255}, {255, 0, 0, 255}, {0, 255, 0, 255}, {255, 255, 0, 255},
+	{0, 0, 255, 255}, {255, 0, 255, 255}, {0, 255, 255, 255}, {255, 255, 255, 255},
+}
+
+func colorDistance(r1, g1, b1, r2, g2, b2 int32) int32 {
+	dr, dg, db := r1-r2, g1-g2, b1-b2
+	return 2*dr*dr + 4*dg*dg + 3*db*db
+}
+
+// nearestCubeLevel returns the index of the cube level closest to v.
+func nearestCubeLevel(v int32) int {
+	best := 0
+	for i, l := range cubeLevels {
+		if abs32(l-v) < abs32(cubeLevels[best]-v) {
+			best = i
+		}
+	}
+	return best
+}
+
+func abs32(v int32) int32 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// quantize256 maps a color to the closest xterm-256 palette entry, choosing
+// between the color cube and the grayscale ramp.
+func quantize256(c color.RGBA) tcell.Color {
+	r, g, b := int32(c.R), int32(c.G), int32(c.B)
+
+	ri, gi, bi := nearestCubeLevel(r), nearestCubeLevel(g), nearestCubeLevel(b)
+	cube := 16 + 36*ri + 6*gi + bi
+	cubeDist := colorDistance(r, g, b, cubeLevels[ri], cubeLevels[gi], cubeLevels[bi])
+
+	gray := min(max((r+g+b)/3-8, 0)/10, 23)
+	level := 8 + 10*gray
+	grayDist := colorDistance(r, g, b, level, level, level)
+
+	if grayDist < cubeDist {
+		return tcell.Color(232 + gray)
+	}
+	return tcell.Color(cube)
+}
+
+// quantize16 maps a color to the closest of the basic 16 colors.
+func quantize16(c color.RGBA) tcell.Color {
+	best, bestDist := 0, int32(-1)
+	for i, p := range palette16 {
+		d := colorDistance(int32(c.R), int32(c.G), int32(c.B), int32(p.R), int32(p.G), int32(p.B))
+		if bestDist < 0 || d < bestDist {
+			best, bestDist = i, d
+		}
+	}
+	return tcell.Color(best)
+}
+
+// terminalColor converts a cell color to a tcell color at the given depth.
+func terminalColor(c color.RGBA, depth colorDepth) tcell.Color {
+	switch depth {
+	case color256:
+		return quantize256(c)
+	case color16:
+		return quantize16(c)
+	}
+	return tcell.NewRGBColor(int32(c.R), int32(c.G), int32(c.B))
+}
+
+// styleCacheBits is the per-channel precision colors are quantized to
+// before looking up a cached style.
+const styleCacheBits = 5
+
+// styleCache memoizes the tcell styles built for cell colors, so drawing a
+// frame does not quantize and construct a style for every cell. Colors are
+// reduced to styleCacheBits per channel to keep the table small.
+type styleCache struct {
+	base       tcell.Style
+	depth      colorDepth
+	background bool
+	styles     [1 << (3 * styleCacheBits)]tcell.Style
+	valid      [1 << (3 * styleCacheBits)]bool
+}
+
+func newStyleCache(background bool) *styleCache {
+	return &styleCache{background: background}
+}
+
+// style returns the style for drawing a cell of color c on top of base.
+func (sc *styleCache) style(c color.RGBA, base tcell.Style, depth colorDepth) tcell.Style {
+	if base != sc.base || depth != sc.depth {
+		sc.base, sc.depth = base, depth
+		sc.valid = [len(sc.valid)]bool{}
+	}
+
+	const shift = 8 - styleCacheBits
+	r, g, b := c.R>>shift, c.G>>shift, c.B>>shift
+	key := int(r)<<(2*styleCacheBits) | int(g)<<styleCacheBits | int(b)
+	if sc.valid[key] {
+		return sc.styles[key]
+	}
+
+	// expand back to 8 bits, replicating the high bits into the low ones
+	expand := func(v uint8) uint8 { return v<<shift | v>>(styleCacheBits-shift) }
+	tc := terminalColor(color.RGBA{expand(r), expand(g), expand(b), 255}, depth)
+
+	style := base.Foreground(tc)
+	if sc.background {
+		style = base.Background(tc)
+	}
+	sc.styles[key] = style
+	sc.valid[key] = true
+	return style
+}
+
+// hexColor formats a color as #rrggbb.
+func hexColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
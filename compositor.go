@@ -0,0 +1,226 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"sort"
+	"time"
+
+	"github.com/gdamore/tcell"
+)
+
+// Z-order of the built-in overlay surfaces.
+const (
+	logLayer      = 10
+	warmupLayer   = 15
+	tutorialLayer = 20
+)
+
+// overlayCell is a cell drawn by an overlay surface. With alpha below 1 the
+// overlay's colors are blended with the video cell underneath, and a blank
+// overlay glyph lets the video glyph show through.
+type overlayCell struct {
+	r     rune
+	style tcell.Style
+	alpha float32
+}
+
+// surface is one overlay layer, holding only the cells it draws.
+type surface struct {
+	name  string
+	z     int
+	cells map[image.Point]overlayCell
+}
+
+// set draws a cell on the surface.
+func (sf *surface) set(x, y int, c overlayCell) {
+	sf.cells[image.Point{X: x, Y: y}] = c
+}
+
+// text draws a string starting at x, y with the given style and alpha.
+func (sf *surface) text(x, y int, text string, style tcell.Style, alpha float32) {
+	for _, r := range text {
+		sf.set(x, y, overlayCell{r: r, style: style, alpha: alpha})
+		x++
+	}
+}
+
+// clear removes everything drawn on the surface.
+func (sf *surface) clear() {
+	sf.cells = map[image.Point]overlayCell{}
+}
+
+// compositor draws the video frame with overlay surfaces stacked on top in
+// z-order. Because it owns the whole screen, removing an overlay restores
+// the video cells underneath.
+type compositor struct {
+	surfaces []*surface
+	video    *frame
+	defStyle tcell.Style
+	// rain, when set, renders the video as matrix rain instead of the ramp.
+	rain *matrixRain
+	// drawn is what the last draw put on the screen, row by row, so only
+	// the cells that changed since are sent to it.
+	drawn          []drawnCell
+	drawnW, drawnH int
+}
+
+// drawnCell is the content of one screen cell.
+type drawnCell struct {
+	r     rune
+	style tcell.Style
+}
+
+var overlays = &compositor{}
+
+// surface returns the named surface, creating it at z-order z if needed.
+func (c *compositor) surface(name string, z int) *surface {
+	for _, sf := range c.surfaces {
+		if sf.name == name {
+			return sf
+		}
+	}
+	sf := &surface{name: name, z: z, cells: map[image.Point]overlayCell{}}
+	c.surfaces = append(c.surfaces, sf)
+	sort.SliceStable(c.surfaces, func(i, j int) bool { return c.surfaces[i].z < c.surfaces[j].z })
+	return sf
+}
+
+// remove deletes the named surface.
+func (c *compositor) remove(name string) {
+	for i, sf := range c.surfaces {
+		if sf.name == name {
+			c.surfaces = append(c.surfaces[:i:i], c.surfaces[i+1:]...)
+			return
+		}
+	}
+}
+
+// setVideo replaces the video frame under the overlays.
+func (c *compositor) setVideo(f *frame) {
+	c.video = f
+}
+
+// videoCell returns how a converted cell is drawn in the current mode.
+func (c *compositor) videoCell(vc cell) (rune, tcell.Style) {
+	r, fg, bg := displayedCell(vc)
+	switch {
+	case bg.A > 0:
+		return r, backgroundStyles.style(bg, c.defStyle, colors)
+	case fg.A > 0:
+		return r, foregroundStyles.style(fg, c.defStyle, colors)
+	}
+	return r, c.defStyle
+}
+
+// displayedCell returns the glyph and colors a converted cell is shown
+// with in the current mode. A color with zero alpha is the terminal's
+// default. A false-color palette or a tint colors the glyphs whether or
+// not color is enabled.
+func displayedCell(vc cell) (r rune, fg, bg color.RGBA) {
+	colored := colorEnabled
+	if p, ok := activePalette(); ok {
+		vc.color = p.at(brightness(vc.color))
+		colored = true
+	} else if tint != tintNone {
+		vc.color = tint.apply(vc.color)
+		colored = true
+	}
+	vc.color.A = 255
+	switch {
+	case pixelEnabled:
+		return ' ', fg, vc.color
+	case colored:
+		return vc.r, vc.color, bg
+	}
+	return vc.r, fg, bg
+}
+
+// blendColor mixes an overlay color over a video color.
+func blendColor(over tcell.Color, under color.RGBA, alpha float32) tcell.Color {
+	r, g, b := over.RGB()
+	mix := func(o int32, u uint8) uint8 {
+		return clampChannel(float32(o)*alpha + float32(u)*(1-alpha))
+	}
+	return terminalColor(color.RGBA{mix(r, under.R), mix(g, under.G), mix(b, under.B), 255}, colors)
+}
+
+// videoBounds returns where the video is drawn on a width x height
+// screen. Frames smaller than the video area, as in the fit mode, are
+// centered in it.
+func (c *compositor) videoBounds(width, height int) image.Rectangle {
+	if c.video == nil {
+		return image.Rectangle{}
+	}
+	x0 := max((width-c.video.width)/2, 0)
+	y0 := max((height-logHeight-c.video.height)/2, 0)
+	return image.Rect(x0, y0, x0+c.video.width, y0+c.video.height)
+}
+
+// invalidate forgets what is on the screen, so the next draw sets every
+// cell again.
+func (c *compositor) invalidate() {
+	c.drawn = nil
+}
+
+// draw puts the video and all overlays on the screen, setting only the
+// cells that changed since the last draw.
+func (c *compositor) draw(s tcell.Screen) {
+	width, height := s.Size()
+	if c.drawn == nil || c.drawnW != width || c.drawnH != height {
+		c.drawn = make([]drawnCell, width*height)
+		c.drawnW, c.drawnH = width, height
+		for i := range c.drawn {
+			// a rune no cell holds, so every cell counts as changed
+			c.drawn[i].r = -1
+		}
+	}
+	if c.rain != nil && c.video != nil {
+		c.rain.advance(c.video.width, c.video.height, time.Now())
+	}
+	bounds := c.videoBounds(width, height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			r, style := ' ', c.defStyle
+			var under cell
+			if at := (image.Point{X: x, Y: y}); at.In(bounds) {
+				vx, vy := x-bounds.Min.X, y-bounds.Min.Y
+				under = c.video.at(vx, vy)
+				under.color = simulateCVD(under.color, cvd)
+				r, style = c.videoCell(under)
+				if c.rain != nil {
+					var rc color.RGBA
+					r, rc = c.rain.cell(vx, vy, under)
+					style = foregroundStyles.style(rc, c.defStyle, colors)
+				}
+			}
+
+			for _, sf := range c.surfaces {
+				oc, ok := sf.cells[image.Point{X: x, Y: y}]
+				if !ok {
+					continue
+				}
+				if oc.alpha >= 1 {
+					r, style = oc.r, oc.style
+					continue
+				}
+
+				fg, bg, _ := oc.style.Decompose()
+				if oc.r != ' ' {
+					r = oc.r
+				}
+				if fg != tcell.ColorDefault {
+					style = style.Foreground(blendColor(fg, under.color, oc.alpha))
+				}
+				if bg != tcell.ColorDefault {
+					style = style.Background(blendColor(bg, under.color, oc.alpha))
+				}
+			}
+
+			if d := &c.drawn[y*width+x]; d.r != r || d.style != style {
+				*d = drawnCell{r, style}
+				s.SetContent(x, y, r, nil, style)
+			}
+		}
+	}
+}
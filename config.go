@@ -0,0 +1,236 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// config is a parsed config file. It understands the subset of TOML the
+// settings need: [sections] holding key = value pairs, where values are
+// strings, numbers, booleans or arrays of those, and # comments. Keys
+// before the first section are named after command line flags and set
+// their defaults, for example:
+//
+//	device = 1
+//	charset = " .:-=+*#%@"
+//	colors = "256"
+//	dither = "bayer"
+//	storage-dir = "captures"
+//
+// The [keys] section rebinds keys by action name, such as quit = "Q".
+type config struct {
+	path     string
+	sections map[string]map[string]any
+}
+
+// defaultConfigPath returns ~/.config/ascii-webcam/config.toml or the
+// platform's equivalent.
+func defaultConfigPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "ascii-webcam", "config.toml"), nil
+}
+
+// loadConfig reads the config file at path. A missing file at the default
+// location is not an error and yields an empty config.
+func loadConfig(path string) (*config, error) {
+	explicit := path != ""
+	if !explicit {
+		var err error
+		if path, err = defaultConfigPath(); err != nil {
+			return &config{sections: map[string]map[string]any{}}, nil
+		}
+	}
+
+	cfg := &config{path: path, sections: map[string]map[string]any{"": {}}}
+	file, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) && !explicit {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	section := ""
+	scanner := bufio.NewScanner(file)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(stripComment(scanner.Text()))
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			if cfg.sections[section] == nil {
+				cfg.sections[section] = map[string]any{}
+			}
+			continue
+		}
+		key, text, ok := strings.Cut(line, "=")
+		key = strings.Trim(strings.TrimSpace(key), `"`)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("%v:%d: expected key = value", path, n)
+		}
+		value, err := parseConfigValue(strings.TrimSpace(text))
+		if err != nil {
+			return nil, fmt.Errorf("%v:%d: %v", path, n, err)
+		}
+		cfg.sections[section][key] = value
+	}
+	return cfg, scanner.Err()
+}
+
+// stripComment removes a # comment that is not inside a string.
+func stripComment(line string) string {
+	quoted := false
+	for i, r := range line {
+		switch {
+		case r == '"' && (i == 0 || line[i-1] != '\\'):
+			quoted = !quoted
+		case r == '#' && !quoted:
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// parseConfigValue parses a string, number, boolean or array value.
+func parseConfigValue(text string) (any, error) {
+	switch {
+	case strings.HasPrefix(text, `"`):
+		return strconv.Unquote(text)
+	case strings.HasPrefix(text, "["):
+		if !strings.HasSuffix(text, "]") {
+			return nil, fmt.Errorf("unterminated array %v", text)
+		}
+		var values []any
+		for _, item := range splitArray(text[1 : len(text)-1]) {
+			v, err := parseConfigValue(item)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, v)
+		}
+		return values, nil
+	case text == "true" || text == "false":
+		return text == "true", nil
+	}
+	if i, err := strconv.ParseInt(text, 10, 64); err == nil {
+		return i, nil
+	}
+	if f, err := strconv.ParseFloat(text, 64); err == nil {
+		return f, nil
+	}
+	return nil, fmt.Errorf("invalid value %v", text)
+}
+
+// splitArray splits the inside of an array at commas outside strings,
+// dropping empty items so trailing commas are allowed.
+func splitArray(text string) []string {
+	var items []string
+	quoted, start := false, 0
+	for i, r := range text {
+		switch {
+		case r == '"' && (i == 0 || text[i-1] != '\\'):
+			quoted = !quoted
+		case r == ',' && !quoted:
+			items = append(items, text[start:i])
+			start = i + 1
+		}
+	}
+	items = append(items, text[start:])
+
+	var trimmed []string
+	for _, item := range items {
+		if item = strings.TrimSpace(item); item != "" {
+			trimmed = append(trimmed, item)
+		}
+	}
+	return trimmed
+}
+
+// section returns the key/value pairs of a section, which may be empty.
+func (c *config) section(name string) map[string]any {
+	return c.sections[name]
+}
+
+// stringValue returns a value as a string, joining arrays with spaces.
+func stringValue(v any) (string, bool) {
+	switch v := v.(type) {
+	case string:
+		return v, true
+	case []any:
+		parts := make([]string, len(v))
+		for i, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return "", false
+			}
+			parts[i] = s
+		}
+		return strings.Join(parts, " "), true
+	}
+	return "", false
+}
+
+// applyFlagDefaults sets the flags named by the top-level keys of cfg,
+// except those given on the command line, which take precedence. Keys
+// that are not flags are errors unless shared is set, for subcommands
+// that only have some of the viewer's flags.
+func applyFlagDefaults(cfg *config, flags *flag.FlagSet, shared bool) error {
+	given := map[string]bool{}
+	flags.Visit(func(f *flag.Flag) { given[f.Name] = true })
+
+	top := cfg.section("")
+	keys := make([]string, 0, len(top))
+	for key := range top {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if flags.Lookup(key) == nil || key == "config" {
+			if shared {
+				continue
+			}
+			return fmt.Errorf("%v: unknown setting %v", cfg.path, key)
+		}
+		if given[key] {
+			continue
+		}
+		text, ok := stringValue(top[key])
+		if !ok {
+			text = fmt.Sprint(top[key])
+		}
+		if err := flags.Set(key, text); err != nil {
+			return fmt.Errorf("%v: %v: %v", cfg.path, key, err)
+		}
+	}
+	return nil
+}
+
+// applyConfig applies the settings of cfg: false-color palettes from the
+// [palettes] section and user commands from [hooks].
+func applyConfig(cfg *config) error {
+	for name, v := range cfg.section("palettes") {
+		text, ok := stringValue(v)
+		if !ok {
+			return fmt.Errorf("%v: palette %v must be a list of colors", cfg.path, name)
+		}
+		stops, err := parseStops(text)
+		if err != nil {
+			return fmt.Errorf("%v: palette %v: %v", cfg.path, name, err)
+		}
+		addPalette(gradient{name: name, stops: stops})
+	}
+	return hooks.configure(cfg)
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"bufio"
+	"image"
+	"image/color"
+	"io"
+
+	"github.com/remzisenel/ascii-webcam/stream"
+)
+
+// cell is a single converted character cell together with the color of
+// the source pixel it was produced from.
+type cell struct {
+	r     rune
+	color color.RGBA
+}
+
+// frame is a converted grid of cells, stored row by row.
+type frame struct {
+	width, height int
+	cells         []cell
+	meta          frameMeta
+}
+
+func newFrame(width, height int) *frame {
+	return &frame{width: width, height: height, cells: make([]cell, width*height)}
+}
+
+// at returns the cell at x, y.
+func (f *frame) at(x, y int) cell {
+	return f.cells[y*f.width+x]
+}
+
+// set stores the cell at x, y.
+func (f *frame) set(x, y int, c cell) {
+	f.cells[y*f.width+x] = c
+}
+
+// writeText writes a frame as plain text, one line per row.
+func writeText(w io.Writer, f *frame) error {
+	bw := bufio.NewWriter(w)
+	for y := 0; y < f.height; y++ {
+		for x := 0; x < f.width; x++ {
+			bw.WriteRune(f.at(x, y).r)
+		}
+		bw.WriteByte('\n')
+	}
+	return bw.Flush()
+}
+
+// brightness returns the perceived luminance of a color in the range [0, 1].
+func brightness(c color.Color) float32 {
+	r, g, b, _ := c.RGBA()
+	return float32(r)/0xffff*0.299 + float32(g)/0xffff*0.587 + float32(b)/0xffff*0.114
+}
+
+// rampLevel returns the brightness used to pick a glyph, after the
+// brightness, contrast and threshold adjustments, inverted for light
+// terminal themes where dense glyphs read as dark.
+func rampLevel(v float32) float32 {
+	v = applyThreshold(adjustLevels(v))
+	if invertEnabled {
+		return 1 - v
+	}
+	return v
+}
+
+// rampTable maps every luma to its ramp brightness and glyph under the
+// current settings, so converting a cell takes table lookups instead of
+// float math per pixel.
+type rampTable struct {
+	levels [256]float32
+	runes  [256]rune
+}
+
+func newRampTable() *rampTable {
+	t := &rampTable{}
+	for i := range t.levels {
+		t.levels[i] = rampLevel(float32(i) / 255)
+		t.runes[i] = stream.RampRune(runes, t.levels[i])
+	}
+	return t
+}
+
+// convertImage converts an image into a frame of cols x rows cells, each
+// cell averaging the block of pixels it covers.
+func convertImage(img image.Image, cols, rows int) *frame {
+	f := newFrame(cols, rows)
+	if cols == 0 || rows == 0 {
+		return f
+	}
+	lum := make([]float32, cols*rows)
+	convertColumns(img, f, lum, 0, cols)
+	finishFrame(img, f, lum)
+	return f
+}
+
+// matchingGlyphs reports whether cells get their glyphs from shape
+// matching rather than the ramp.
+func matchingGlyphs(img image.Image, cols, rows int) bool {
+	bw, bh := stream.BlockSize(img.Bounds(), cols, rows)
+	return glyphEnabled && bw == glyphWidth && bh == glyphHeight
+}
+
+// convertColumns converts the cells of columns x0 to x1 of f, storing
+// their ramp brightness in lum.
+func convertColumns(img image.Image, f *frame, lum []float32, x0, x1 int) {
+	converters.convert(img, f, lum, x0, x1, 0, 1)
+}
+
+// convertCells converts the cells of columns x0 to x1 of f in every dy-th
+// row from y0 up to y1, storing their ramp brightness in lum.
+func convertCells(img image.Image, f *frame, lum []float32, x0, x1, y0, y1, dy int) {
+	cols, rows := f.width, f.height
+	bw, bh := stream.BlockSize(img.Bounds(), cols, rows)
+
+	var block []float32
+	if matchingGlyphs(img, cols, rows) {
+		block = make([]float32, bw*bh)
+	}
+
+	pixels := stream.NewPixels(img)
+	// the settings may change between calls but not within one, and the
+	// table is cheap next to a frame
+	ramp := newRampTable()
+
+	for y := y0; y < y1; y += dy {
+		for x := x0; x < x1; x++ {
+			var sr, sg, sb, sa uint32
+			for py := 0; py < bh; py++ {
+				for px := 0; px < bw; px++ {
+					pixelColor := pixels.At(x*bw+px, y*bh+py)
+					sr, sg = sr+uint32(pixelColor.R), sg+uint32(pixelColor.G)
+					sb, sa = sb+uint32(pixelColor.B), sa+uint32(pixelColor.A)
+					if block != nil {
+						block[py*bw+px] = ramp.levels[stream.Luma(applyFilters(pixelColor))]
+					}
+				}
+			}
+			n := uint32(bw * bh)
+			avg := applyFilters(color.RGBA{uint8(sr / n), uint8(sg / n), uint8(sb / n), uint8(sa / n)})
+
+			l := stream.Luma(avg)
+			lum[y*cols+x] = ramp.levels[l]
+			r := ramp.runes[l]
+			if block != nil {
+				r = matchGlyph(block)
+			}
+			cc := adjustColor(avg)
+			if thresholdEnabled {
+				cc = thresholdColor(avg)
+			}
+			f.set(x, y, cell{r: r, color: cc})
+		}
+	}
+}
+
+// finishFrame runs the passes that look at the whole frame, dithering and
+// edge detection, after all cells were converted.
+func finishFrame(img image.Image, f *frame, lum []float32) {
+	if !matchingGlyphs(img, f.width, f.height) {
+		switch dither {
+		case ditherBayer:
+			ditherBayerOrdered(f, lum)
+		case ditherFloydSteinbergMode:
+			ditherFloydSteinberg(f, lum)
+		}
+	}
+	if edgesEnabled {
+		applyEdges(f, lum)
+	}
+}
+
+// maxSupersample limits supersampling, whose cost grows with its square.
+const maxSupersample = 4
+
+// supersample is the number of source pixels averaged per cell along each
+// axis. Averaging a block instead of reading one pixel stops fine detail
+// from shimmering as it moves across cell boundaries.
+var supersample = 1
+
+// cellSamples returns how many source pixels per cell, horizontally and
+// vertically, the current render mode wants the capture to provide.
+func cellSamples() (int, int) {
+	if glyphEnabled {
+		return glyphWidth, glyphHeight
+	}
+	n := min(max(supersample, 1), maxSupersample)
+	return n, n
+}
@@ -0,0 +1,252 @@
+//go:build !minimal
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gocv.io/x/gocv"
+)
+
+func init() {
+	subcommands["convert"] = subcommand{run: runConvert, summary: "convert an image or video file to text, .cast, .html, .gif or .mp4", failure: "Error converting"}
+}
+
+// parseInterspersed parses flags that may appear before or after positional
+// arguments, returning the positional ones.
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		if fs.NArg() == 0 {
+			return positional, nil
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
+
+// isImageFile reports whether path looks like a still image rather than a video.
+func isImageFile(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".jpg", ".jpeg", ".png", ".bmp", ".webp", ".tif", ".tiff":
+		return true
+	}
+	return false
+}
+
+// frameWriterFor picks an exporter constructor from the extension of path.
+func frameWriterFor(path string, color bool) (func(*os.File) frameWriter, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".txt":
+		return func(f *os.File) frameWriter { return &textWriter{w: f} }, nil
+	case ".html", ".htm":
+		return func(f *os.File) frameWriter { return newHTMLWriter(f, color) }, nil
+	case ".cast":
+		return func(f *os.File) frameWriter { return newCastWriter(f, color) }, nil
+	case ".png":
+		return func(f *os.File) frameWriter { return &pngWriter{w: f, color: color} }, nil
+	case ".svg":
+		return func(f *os.File) frameWriter { return &svgWriter{w: f, color: color} }, nil
+	case ".json", ".jsonl":
+		return func(f *os.File) frameWriter { return &jsonWriter{w: f, color: color} }, nil
+	case ".gif":
+		return func(f *os.File) frameWriter { return &gifWriter{w: f, color: color} }, nil
+	case ".mp4":
+		return func(f *os.File) frameWriter { return &videoWriter{file: f, color: color} }, nil
+	}
+	return nil, fmt.Errorf("unsupported output format %q", filepath.Ext(path))
+}
+
+// createFrameWriter creates a hidden temporary file next to path and an
+// exporter matching its extension. finishOutput moves the file to path once
+// it is complete, so nothing at path is touched before the input was read
+// and a failed export leaves no partial file behind.
+func createFrameWriter(path string, color bool) (frameWriter, *os.File, error) {
+	newWriter, err := frameWriterFor(path, color)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// the temporary file keeps the extension, which OpenCV picks the
+	// container of videos by
+	ext := filepath.Ext(path)
+	file, err := os.CreateTemp(filepath.Dir(path), "."+strings.TrimSuffix(filepath.Base(path), ext)+"-*"+ext)
+	if err != nil {
+		return nil, nil, err
+	}
+	return newWriter(file), file, nil
+}
+
+// finishOutput closes a file made by createFrameWriter and renames it to path.
+func finishOutput(file *os.File, path string) error {
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), path)
+}
+
+// discardOutput removes a file made by createFrameWriter unless
+// finishOutput already moved it into place.
+func discardOutput(file *os.File) {
+	file.Close()
+	os.Remove(file.Name())
+}
+
+// samePath reports whether a and b name the same file.
+func samePath(a, b string) bool {
+	ia, errA := os.Stat(a)
+	ib, errB := os.Stat(b)
+	if errA == nil && errB == nil {
+		return os.SameFile(ia, ib)
+	}
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	return errA == nil && errB == nil && absA == absB
+}
+
+// outputSize returns the character grid size for a source of the given
+// dimensions, deriving a missing height from the aspect ratio.
+func outputSize(srcWidth, srcHeight, width, height int) (int, int) {
+	if height <= 0 {
+		height = int(float64(width) * float64(srcHeight) / float64(srcWidth) * charAspect)
+		if height < 1 {
+			height = 1
+		}
+	}
+	return width, height
+}
+
+// runConvert implements the convert subcommand, which runs the conversion
+// pipeline on an image or video file instead of the webcam.
+func runConvert(args []string) error {
+	fs := flag.NewFlagSet("convert", flag.ExitOnError)
+	out := fs.String("out", "", "output file (.txt, .cast, .html, .png, .svg, .json, .gif or .mp4)")
+	width := fs.Int("width", 80, "output width in characters")
+	height := fs.Int("height", 0, "output height in characters (default: keep aspect ratio)")
+	color := fs.Bool("color", false, "include colors in .cast, .html, .png, .svg and .json output")
+	fs.BoolVar(&edgesEnabled, "edges", false, "draw strong edges with orientation-matched glyphs")
+	fs.BoolVar(&glyphEnabled, "glyphs", false, "pick glyphs by matching their shape against the image")
+	fs.Var(&dither, "dither", "dithering: none, bayer or fs")
+	fs.IntVar(&supersample, "supersample", 1, "average NxN source pixels per cell to reduce shimmering, up to 4 (1 disables)")
+	interp := interpolationLinear
+	fs.Var(&interp, "interpolation", "resize interpolation: nearest, linear, area, cubic or lanczos")
+	fs.Var(&deinterlacing, "deinterlace", "deinterlace the input: none, blend or bob")
+	fs.BoolVar(&invertEnabled, "invert", false, "invert the glyph ramp for light backgrounds")
+	fs.BoolVar(&thresholdEnabled, "threshold", false, "render only the darkest and densest glyphs")
+	whiteBalance := fs.Bool("white-balance", false, "remove color casts with gray-world auto white balance")
+	clahe := fs.Bool("clahe", false, "equalize contrast locally so dark or flat images use the full ramp")
+	cartoon := fs.Bool("cartoon", false, "flatten colors and outline edges for a comic look")
+	denoise := fs.Float64("denoise", 0, "temporal denoise strength for videos, up to 0.9 (0 disables)")
+	onionSkin := fs.Int("onion-skin", 0, "blend up to 8 previous video frames into each frame as fading trails (0 disables)")
+	sharpen := fs.Float64("sharpen", 0, "unsharp mask strength applied after resizing (0 disables)")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s convert [flags] input.jpg|input.mp4 --out out.txt|out.cast|out.html|out.png|out.svg|out.json|out.gif|out.mp4\n", filepath.Base(os.Args[0]))
+		fs.PrintDefaults()
+	}
+
+	inputs, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(inputs) != 1 || *out == "" {
+		fs.Usage()
+		os.Exit(2)
+	}
+	if *whiteBalance {
+		toggleImageFilter(whiteBalanceFilter{})
+	}
+	if *clahe {
+		toggleImageFilter(claheFilter{})
+	}
+	if *cartoon {
+		toggleImageFilter(cartoonFilter{})
+	}
+	setSharpen(*sharpen)
+	setDenoise(*denoise)
+	setOnionSkin(*onionSkin)
+	interpolation.Store(int32(interp))
+
+	return convertFile(inputs[0], *out, *width, *height, *color)
+}
+
+// convertFile converts a single image or video file into an export file.
+func convertFile(input, output string, width, height int, color bool) error {
+	if samePath(input, output) {
+		return fmt.Errorf("%v would overwrite its input", output)
+	}
+	w, file, err := createFrameWriter(output, color)
+	if err != nil {
+		return err
+	}
+	defer discardOutput(file)
+
+	small := gocv.NewMat()
+	defer small.Close()
+
+	scratch := gocv.NewMat()
+	defer scratch.Close()
+
+	sx, sy := cellSamples()
+	if isImageFile(input) {
+		img := gocv.IMRead(input, gocv.IMReadColor)
+		defer img.Close()
+		if img.Empty() {
+			return fmt.Errorf("could not read image %v", input)
+		}
+		deinterlace(&img, &scratch, deinterlacing)
+
+		cols, rows := outputSize(img.Cols(), img.Rows(), width, height)
+		smallImage, err := resizeImage(img, &small, cols*sx, rows*sy)
+		if err != nil {
+			return err
+		}
+		f := convertImage(smallImage, cols, rows)
+		f.meta = frameMeta{captured: time.Now(), source: input}
+		if err := w.writeFrame(f, 0); err != nil {
+			return err
+		}
+	} else {
+		video, err := gocv.VideoCaptureFile(input)
+		if err != nil {
+			return fmt.Errorf("could not open video %v: %v", input, err)
+		}
+		defer video.Close()
+
+		fps := video.Get(gocv.VideoCaptureFPS)
+		if fps <= 0 {
+			fps = 30
+		}
+
+		img := gocv.NewMat()
+		defer img.Close()
+
+		start := time.Now()
+		for n := 0; video.Read(&img) && !img.Empty(); n++ {
+			deinterlace(&img, &scratch, deinterlacing)
+			cols, rows := outputSize(img.Cols(), img.Rows(), width, height)
+			smallImage, err := resizeImage(img, &small, cols*sx, rows*sy)
+			if err != nil {
+				return err
+			}
+			at := time.Duration(float64(n) / fps * float64(time.Second))
+			f := convertImage(smallImage, cols, rows)
+			f.meta = frameMeta{captured: start.Add(at), source: input}
+			if err := w.writeFrame(f, at); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := w.close(); err != nil {
+		return err
+	}
+	return finishOutput(file, output)
+}
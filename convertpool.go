@@ -0,0 +1,61 @@
+package main
+
+import (
+	"image"
+	"runtime"
+	"sync"
+)
+
+// minBandRows is the fewest rows worth handing to a conversion worker;
+// smaller frames are converted without the pool.
+const minBandRows = 8
+
+// convertPool converts the row bands of a frame on a fixed set of worker
+// goroutines, one per processor. The cells of a band are written straight
+// into the frame, which is safe since bands never share a row.
+type convertPool struct {
+	once    sync.Once
+	workers int
+	bands   chan func()
+}
+
+var converters = &convertPool{}
+
+// start launches the workers on first use.
+func (p *convertPool) start() {
+	p.once.Do(func() {
+		p.workers = runtime.GOMAXPROCS(0)
+		p.bands = make(chan func(), p.workers)
+		for i := 0; i < p.workers; i++ {
+			go func() {
+				for band := range p.bands {
+					band()
+				}
+			}()
+		}
+	})
+}
+
+// convert converts the cells of columns x0 to x1 of f in every dy-th row
+// starting at y0, like convertCells, splitting the rows into one band per
+// worker and waiting for all of them.
+func (p *convertPool) convert(img image.Image, f *frame, lum []float32, x0, x1, y0, dy int) {
+	p.start()
+	n := (f.height - y0 + dy - 1) / dy
+	bands := min(p.workers, n/minBandRows)
+	if bands < 2 {
+		convertCells(img, f, lum, x0, x1, y0, f.height, dy)
+		return
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(bands)
+	for b := 0; b < bands; b++ {
+		start, end := y0+n*b/bands*dy, y0+n*(b+1)/bands*dy
+		p.bands <- func() {
+			defer wg.Done()
+			convertCells(img, f, lum, x0, x1, start, min(end, f.height), dy)
+		}
+	}
+	wg.Wait()
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"image"
+	"sync"
+
+	"github.com/gdamore/tcell"
+)
+
+// cropLayer is the z-order of the crop selection while it is dragged.
+const cropLayer = 12
+
+// normRect is a rectangle in normalized coordinates, where the frame spans
+// 0 to 1 on both axes.
+type normRect struct {
+	x0, y0, x1, y1 float64
+}
+
+var fullFrame = normRect{0, 0, 1, 1}
+
+// within maps r, given relative to outer, to the coordinates outer is in.
+func (r normRect) within(outer normRect) normRect {
+	w, h := outer.x1-outer.x0, outer.y1-outer.y0
+	return normRect{outer.x0 + r.x0*w, outer.y0 + r.y0*h, outer.x0 + r.x1*w, outer.y0 + r.y1*h}
+}
+
+// pixels returns r on a width x height frame, at least one pixel large.
+func (r normRect) pixels(width, height int) image.Rectangle {
+	x0 := min(max(int(r.x0*float64(width)), 0), width-1)
+	y0 := min(max(int(r.y0*float64(height)), 0), height-1)
+	x1 := min(max(int(r.x1*float64(width)), x0+1), width)
+	y1 := min(max(int(r.y1*float64(height)), y0+1), height)
+	return image.Rect(x0, y0, x1, y1)
+}
+
+// cropper is the persistent crop region of the camera frame, selected by
+// dragging with the mouse. It is set by the UI and read by the capture
+// goroutine.
+type cropper struct {
+	mu   sync.Mutex
+	rect normRect
+}
+
+var frameCrop = &cropper{rect: fullFrame}
+
+// set crops to sel, given relative to what is currently on screen, and
+// zooms back out so the whole crop region is shown.
+func (c *cropper) set(sel normRect) {
+	visible := view.bounds()
+	c.mu.Lock()
+	c.rect = sel.within(visible.within(c.rect))
+	c.mu.Unlock()
+	view.reset()
+}
+
+// clear removes the crop region.
+func (c *cropper) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.rect = fullFrame
+}
+
+// get returns the crop region.
+func (c *cropper) get() normRect {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.rect
+}
+
+// visibleRegion returns the part of a width x height frame shown after
+// cropping and zooming. It returns false when the whole frame is shown.
+func visibleRegion(width, height int) (image.Rectangle, bool) {
+	r := view.bounds().within(frameCrop.get())
+	if r == fullFrame {
+		return image.Rectangle{}, false
+	}
+	return r.pixels(width, height), true
+}
+
+// cropDrag is the state of a crop selection dragged with the mouse, in
+// screen cells.
+type cropDrag struct {
+	rect image.Rectangle
+	done bool
+}
+
+// dragParser turns mouse events into crop selections.
+type dragParser struct {
+	dragging bool
+	start    image.Point
+}
+
+// feed processes a mouse event, returning the selection it changes.
+func (d *dragParser) feed(ev *tcell.EventMouse) (cropDrag, bool) {
+	x, y := ev.Position()
+	at := image.Point{X: x, Y: y}
+	switch {
+	case ev.Buttons()&tcell.Button1 != 0:
+		if !d.dragging {
+			d.dragging = true
+			d.start = at
+		}
+		return cropDrag{rect: image.Rectangle{Min: d.start, Max: at}.Canon()}, true
+	case d.dragging && ev.Buttons() == tcell.ButtonNone:
+		d.dragging = false
+		return cropDrag{rect: image.Rectangle{Min: d.start, Max: at}.Canon(), done: true}, true
+	}
+	return cropDrag{}, false
+}
+
+// drawCropDrag outlines the selection being dragged.
+func drawCropDrag(rect image.Rectangle) {
+	sf := overlays.surface("crop", cropLayer)
+	sf.clear()
+	c := overlayCell{r: ' ', style: tcell.StyleDefault.Background(palette.highlight), alpha: 0.5}
+	for x := rect.Min.X; x <= rect.Max.X; x++ {
+		sf.set(x, rect.Min.Y, c)
+		sf.set(x, rect.Max.Y, c)
+	}
+	for y := rect.Min.Y; y <= rect.Max.Y; y++ {
+		sf.set(rect.Min.X, y, c)
+		sf.set(rect.Max.X, y, c)
+	}
+}
+
+// finishCropDrag removes the selection outline and crops to the selected
+// cells of the video drawn at bounds. It returns false for selections too
+// small to be meant as a crop, such as plain clicks.
+func finishCropDrag(rect, bounds image.Rectangle) bool {
+	overlays.remove("crop")
+	// the selection includes the cells under both corners
+	rect.Max = rect.Max.Add(image.Point{X: 1, Y: 1})
+	rect = rect.Intersect(bounds).Sub(bounds.Min)
+	if rect.Dx() < 2 || rect.Dy() < 2 {
+		return false
+	}
+	cols, rows := float64(bounds.Dx()), float64(bounds.Dy())
+	frameCrop.set(normRect{
+		float64(rect.Min.X) / cols,
+		float64(rect.Min.Y) / rows,
+		float64(rect.Max.X) / cols,
+		float64(rect.Max.Y) / rows,
+	})
+	return true
+}
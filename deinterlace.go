@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"image"
+
+	"gocv.io/x/gocv"
+)
+
+// deinterlaceMode selects how interlaced frames are combined, since the
+// combing between their two fields turns into glyph noise.
+type deinterlaceMode int
+
+const (
+	deinterlaceNone deinterlaceMode = iota
+	// deinterlaceBlend mixes each line with its neighbours, merging the
+	// fields at the cost of some vertical detail.
+	deinterlaceBlend
+	// deinterlaceBob keeps only the even field and interpolates the odd
+	// lines from it.
+	deinterlaceBob
+)
+
+var deinterlaceNames = []string{"none", "blend", "bob"}
+
+func (d deinterlaceMode) String() string {
+	return deinterlaceNames[d]
+}
+
+// Set implements flag.Value.
+func (d *deinterlaceMode) Set(name string) error {
+	for i, n := range deinterlaceNames {
+		if n == name {
+			*d = deinterlaceMode(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown deinterlace mode %q", name)
+}
+
+// deinterlace removes combing from img in place, using scratch for
+// intermediate results.
+func deinterlace(img, scratch *gocv.Mat, mode deinterlaceMode) {
+	if img.Empty() || img.Rows() < 2 {
+		return
+	}
+	switch mode {
+	case deinterlaceBlend:
+		gocv.GaussianBlur(*img, img, image.Point{X: 1, Y: 3}, 0, 0, gocv.BorderDefault)
+	case deinterlaceBob:
+		size := image.Point{X: img.Cols(), Y: img.Rows()}
+		gocv.Resize(*img, scratch, image.Point{X: size.X, Y: size.Y / 2}, 0, 0, gocv.InterpolationNearestNeighbor)
+		gocv.Resize(*scratch, img, size, 0, 0, gocv.InterpolationLinear)
+	}
+}
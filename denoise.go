@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+
+	"gocv.io/x/gocv"
+)
+
+const (
+	denoiseStep = 0.1
+	maxDenoise  = 0.9
+)
+
+// denoiseAmount is the strength of the temporal denoise filter, or 0 when
+// it is off.
+var denoiseAmount float64 = 0
+
+// denoiseAverage is the running average kept by the denoise filter. It
+// outlives filter values so changing the strength keeps the history.
+var denoiseAverage *gocv.Mat
+
+// denoiseFilter smooths each pixel over time with an exponential running
+// average, which removes the sensor noise that makes glyphs flicker in dark
+// scenes. Higher strengths give older frames more weight, at the cost of
+// trails behind moving objects.
+type denoiseFilter struct {
+	strength float64
+}
+
+func (denoiseFilter) name() string { return "denoise" }
+
+func (f denoiseFilter) apply(img *gocv.Mat) {
+	if denoiseAverage == nil {
+		m := gocv.NewMat()
+		denoiseAverage = &m
+	}
+	if denoiseAverage.Rows() != img.Rows() || denoiseAverage.Cols() != img.Cols() || denoiseAverage.Channels() != img.Channels() {
+		// convertTo keeps the channel count and only changes the depth
+		img.ConvertTo(denoiseAverage, gocv.MatTypeCV32F)
+		return
+	}
+	gocv.AccumulatedWeighted(*img, denoiseAverage, 1-f.strength)
+	denoiseAverage.ConvertTo(img, img.Type())
+}
+
+// setDenoise sets the denoise strength, turning the filter off at 0.
+func setDenoise(amount float64) {
+	denoiseAmount = min(max(amount, 0), maxDenoise)
+	if denoiseAmount < denoiseStep/2 {
+		denoiseAmount = 0
+		removeImageFilter(denoiseFilter{}.name())
+		return
+	}
+	setImageFilter(denoiseFilter{strength: denoiseAmount})
+}
+
+// changeDenoise moves the denoise strength by steps.
+func changeDenoise(steps int) {
+	setDenoise(denoiseAmount + float64(steps)*denoiseStep)
+}
+
+func denoiseReadout() string {
+	if denoiseAmount == 0 {
+		return "Denoise: off"
+	}
+	return fmt.Sprintf("Denoise: %.1f", denoiseAmount)
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gocv.io/x/gocv"
+)
+
+func init() {
+	subcommands["devices"] = subcommand{run: runDevices, summary: "list the cameras that can be opened", failure: "Error listing devices"}
+}
+
+// runDevices implements the devices subcommand, which tries to open every
+// device number up to -max and prints the ones that deliver frames.
+func runDevices(args []string) error {
+	fs := flag.NewFlagSet("devices", flag.ExitOnError)
+	maxDevice := fs.Int("max", 9, "highest device number to try")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s devices [flags]\n", filepath.Base(os.Args[0]))
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() > 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	img := gocv.NewMat()
+	defer img.Close()
+
+	found := 0
+	for id := 0; id <= *maxDevice; id++ {
+		vc, err := gocv.VideoCaptureDevice(id)
+		if err != nil {
+			continue
+		}
+		if vc.Read(&img) && !img.Empty() {
+			fmt.Printf("%d\t%dx%d\t%.0f fps\t%s\n", id, img.Cols(), img.Rows(), vc.Get(gocv.VideoCaptureFPS), vc.CodecString())
+			found++
+		}
+		vc.Close()
+	}
+	if found == 0 {
+		return fmt.Errorf("no camera found up to device %d", *maxDevice)
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+)
+
+// ditherMode selects how luminance is spread over the glyph ramp.
+type ditherMode int
+
+const (
+	ditherNone ditherMode = iota
+	ditherBayer
+	ditherFloydSteinbergMode
+)
+
+var ditherNames = []string{"none", "bayer", "fs"}
+
+func (d ditherMode) String() string {
+	return ditherNames[d]
+}
+
+// Set implements flag.Value.
+func (d *ditherMode) Set(name string) error {
+	for i, n := range ditherNames {
+		if n == name {
+			*d = ditherMode(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown dither mode %q", name)
+}
+
+// next returns the mode following d, wrapping around.
+func (d ditherMode) next() ditherMode {
+	return (d + 1) % ditherMode(len(ditherNames))
+}
+
+// bayerMatrix is the 4x4 ordered dithering threshold map.
+var bayerMatrix = [4][4]float32{
+	{0, 8, 2, 10},
+	{12, 4, 14, 6},
+	{3, 11, 1, 9},
+	{15, 7, 13, 5},
+}
+
+// ditherBayerOrdered maps luminance to ramp glyphs using a fixed threshold
+// pattern. Unlike error diffusion the result only depends on each cell's own
+// value, so static areas stay stable from frame to frame.
+func ditherBayerOrdered(f *frame, lum []float32) {
+	levels := len(runes) - 1
+	for y := 0; y < f.height; y++ {
+		for x := 0; x < f.width; x++ {
+			threshold := (bayerMatrix[y%4][x%4] + 0.5) / 16
+			index := min(int(lum[y*f.width+x]*float32(levels)+threshold), levels)
+
+			c := f.at(x, y)
+			c.r = runes[max(index, 0)]
+			f.set(x, y, c)
+		}
+	}
+}
+
+// ditherFloydSteinberg maps luminance to ramp glyphs, diffusing each cell's
+// quantization error to its unvisited neighbours so smooth gradients get a
+// dithered texture instead of bands.
+func ditherFloydSteinberg(f *frame, lum []float32) {
+	levels := float32(len(runes) - 1)
+	buf := append([]float32(nil), lum...)
+
+	diffuse := func(x, y int, e float32) {
+		if x >= 0 && x < f.width && y < f.height {
+			buf[y*f.width+x] += e
+		}
+	}
+
+	for y := 0; y < f.height; y++ {
+		for x := 0; x < f.width; x++ {
+			v := min(max(buf[y*f.width+x], 0), 1)
+			index := int(v*levels + 0.5)
+			e := v - float32(index)/levels
+
+			diffuse(x+1, y, e*7/16)
+			diffuse(x-1, y+1, e*3/16)
+			diffuse(x, y+1, e*5/16)
+			diffuse(x+1, y+1, e*1/16)
+
+			c := f.at(x, y)
+			c.r = runes[index]
+			f.set(x, y, c)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"math"
+)
+
+const (
+	// edgeThreshold is the Sobel gradient magnitude, in luminance units,
+	// above which a cell is drawn as an edge glyph.
+	edgeThreshold = 0.6
+)
+
+// sobel returns the horizontal and vertical Sobel gradients of a
+// luminance grid at x, y, clamping reads at the borders.
+func sobel(lum []float32, width, height, x, y int) (float64, float64) {
+	at := func(dx, dy int) float64 {
+		px, py := x+dx, y+dy
+		if px < 0 {
+			px = 0
+		} else if px >= width {
+			px = width - 1
+		}
+		if py < 0 {
+			py = 0
+		} else if py >= height {
+			py = height - 1
+		}
+		return float64(lum[py*width+px])
+	}
+
+	gx := at(1, -1) + 2*at(1, 0) + at(1, 1) - at(-1, -1) - 2*at(-1, 0) - at(-1, 1)
+	gy := at(-1, 1) + 2*at(0, 1) + at(1, 1) - at(-1, -1) - 2*at(0, -1) - at(1, -1)
+	return gx, gy
+}
+
+// edgeRune picks a glyph matching the orientation of the edge that runs
+// perpendicular to the gradient gx, gy.
+func edgeRune(gx, gy float64) rune {
+	// gradient angle folded into [0, 180) degrees, y pointing down
+	angle := math.Atan2(gy, gx) * 180 / math.Pi
+	if angle < 0 {
+		angle += 180
+	}
+
+	switch {
+	case angle < 22.5 || angle >= 157.5:
+		return '|'
+	case angle < 67.5:
+		return '/'
+	case angle < 112.5:
+		return '-'
+	default:
+		return '\\'
+	}
+}
+
+// applyEdges replaces the glyphs of cells on strong edges with
+// orientation-matched line characters, leaving the brightness ramp elsewhere.
+func applyEdges(f *frame, lum []float32) {
+	for y := 0; y < f.height; y++ {
+		for x := 0; x < f.width; x++ {
+			gx, gy := sobel(lum, f.width, f.height, x, y)
+			if math.Hypot(gx, gy) < edgeThreshold {
+				continue
+			}
+			c := f.at(x, y)
+			c.r = edgeRune(gx, gy)
+			f.set(x, y, c)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+//go:build !minimal
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"io"
+	"strings"
+	"time"
+)
+
+// frameWriter is an export target that accepts a sequence of frames.
+type frameWriter interface {
+	// writeFrame appends a frame shown at the given offset from the start.
+	writeFrame(f *frame, at time.Duration) error
+	close() error
+}
+
+// ansiFrame renders a frame as text with ANSI truecolor escapes when
+// color is set. Rows are separated by CRLF so it can be replayed verbatim
+// on a terminal in raw mode.
+func ansiFrame(f *frame, color bool) string {
+	var sb strings.Builder
+	for y := 0; y < f.height; y++ {
+		if y > 0 {
+			sb.WriteString("\r\n")
+		}
+		for x := 0; x < f.width; x++ {
+			c := f.at(x, y)
+			if color && (x == 0 || f.at(x-1, y).color != c.color) {
+				fmt.Fprintf(&sb, "\x1b[38;2;%d;%d;%dm", c.color.R, c.color.G, c.color.B)
+			}
+			sb.WriteRune(c.r)
+		}
+		if color {
+			sb.WriteString("\x1b[0m")
+		}
+	}
+	return sb.String()
+}
+
+// ansiShownFrame renders a frame as shown, like ansiFrame but with the
+// display colors, foreground and background.
+func ansiShownFrame(sf *shownFrame) string {
+	var sb strings.Builder
+	for y := 0; y < sf.height; y++ {
+		if y > 0 {
+			sb.WriteString("\r\n")
+		}
+		var fg, bg color.RGBA
+		for x := 0; x < sf.width; x++ {
+			c := sf.at(x, y)
+			if c.fg != fg || c.bg != bg {
+				sb.WriteString(ansiStyle(c.fg, c.bg))
+				fg, bg = c.fg, c.bg
+			}
+			sb.WriteRune(c.r)
+		}
+		sb.WriteString("\x1b[0m")
+	}
+	return sb.String()
+}
+
+// textWriter writes frames as plain text separated by blank lines.
+type textWriter struct {
+	w      io.Writer
+	frames int
+}
+
+func (tw *textWriter) writeFrame(f *frame, at time.Duration) error {
+	if tw.frames > 0 {
+		if _, err := io.WriteString(tw.w, "\n"); err != nil {
+			return err
+		}
+	}
+	tw.frames++
+	return writeText(tw.w, f)
+}
+
+func (tw *textWriter) close() error {
+	return nil
+}
+
+// htmlWriter writes frames as a standalone HTML page, one <pre> block per frame.
+type htmlWriter struct {
+	w     *bufio.Writer
+	color bool
+}
+
+func newHTMLWriter(w io.Writer, color bool) *htmlWriter {
+	hw := &htmlWriter{w: bufio.NewWriter(w), color: color}
+	writeHTMLHeader(hw.w)
+	return hw
+}
+
+func (hw *htmlWriter) writeFrame(f *frame, at time.Duration) error {
+	return writeHTMLFrame(hw.w, newShownFrame(f, hw.color))
+}
+
+func (hw *htmlWriter) close() error {
+	writeHTMLFooter(hw.w)
+	return hw.w.Flush()
+}
+
+// castWriter writes frames as an asciinema v2 recording.
+type castWriter struct {
+	w      *bufio.Writer
+	color  bool
+	header bool
+}
+
+func newCastWriter(w io.Writer, color bool) *castWriter {
+	return &castWriter{w: bufio.NewWriter(w), color: color}
+}
+
+// writeFrame appends a frame. The header is written lazily so it can use
+// the size of the first frame.
+func (cw *castWriter) writeFrame(f *frame, at time.Duration) error {
+	if err := cw.writeHeader(f.width, f.height); err != nil {
+		return err
+	}
+	return cw.writeEvent(at, "\x1b[H"+ansiFrame(f, cw.color))
+}
+
+// writeShownFrame appends a frame as it was shown, in its display colors.
+func (cw *castWriter) writeShownFrame(sf *shownFrame, at time.Duration) error {
+	if err := cw.writeHeader(sf.width, sf.height); err != nil {
+		return err
+	}
+	return cw.writeEvent(at, "\x1b[H"+ansiShownFrame(sf))
+}
+
+func (cw *castWriter) writeEvent(at time.Duration, data string) error {
+	ev, err := json.Marshal([]interface{}{at.Seconds(), "o", data})
+	if err != nil {
+		return err
+	}
+	cw.w.Write(ev)
+	_, err = cw.w.WriteString("\n")
+	return err
+}
+
+func (cw *castWriter) writeHeader(width, height int) error {
+	if cw.header {
+		return nil
+	}
+	header, err := json.Marshal(map[string]interface{}{
+		"version":   2,
+		"width":     width,
+		"height":    height,
+		"timestamp": time.Now().Unix(),
+	})
+	if err != nil {
+		return err
+	}
+	cw.w.Write(header)
+	cw.w.WriteByte('\n')
+	cw.header = true
+	return nil
+}
+
+func (cw *castWriter) close() error {
+	return cw.w.Flush()
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"runtime"
+	"sync/atomic"
+)
+
+const (
+	// exportQueueSize bounds how many exports may wait for a worker before
+	// new ones are rejected instead of blocking the viewer.
+	exportQueueSize = 16
+)
+
+// exportJob is a unit of background export work. run returns the name of
+// the file it produced.
+type exportJob struct {
+	name string
+	run  func() (string, error)
+}
+
+// exportResult reports a finished export job.
+type exportResult struct {
+	name    string
+	output  string
+	err     error
+	pending int
+}
+
+// exportPool encodes exports on a fixed set of worker goroutines so that
+// slow encoders never block the capture and render loop.
+type exportPool struct {
+	jobs    chan exportJob
+	results chan exportResult
+	pending atomic.Int32
+}
+
+func newExportPool(workers int) *exportPool {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	p := &exportPool{
+		jobs:    make(chan exportJob, exportQueueSize),
+		results: make(chan exportResult, exportQueueSize),
+	}
+	for i := 0; i < workers; i++ {
+		go p.worker()
+	}
+	return p
+}
+
+func (p *exportPool) worker() {
+	for job := range p.jobs {
+		output, err := job.run()
+		pending := p.pending.Add(-1)
+		p.results <- exportResult{name: job.name, output: output, err: err, pending: int(pending)}
+	}
+}
+
+// submit queues a job, returning false if the queue is full.
+func (p *exportPool) submit(job exportJob) bool {
+	p.pending.Add(1)
+	select {
+	case p.jobs <- job:
+		return true
+	default:
+		p.pending.Add(-1)
+		return false
+	}
+}
+
+// queued returns the number of jobs submitted but not yet finished.
+func (p *exportPool) queued() int {
+	return int(p.pending.Load())
+}
@@ -0,0 +1,56 @@
+package main
+
+const (
+	// exposureLow and exposureHigh are the percentiles of cell brightness
+	// auto-exposure stretches over the ramp.
+	exposureLow  = 0.05
+	exposureHigh = 0.95
+	// exposureSpan is the part of the ramp the percentiles are mapped to.
+	exposureSpan = 0.9
+	// exposureRate is how far the levels move towards their target per
+	// frame, so exposure changes smoothly.
+	exposureRate = 0.1
+	exposureBins = 64
+)
+
+// autoExposure continuously sets brightness and contrast from the frame
+// histogram, so cameras with poor exposure control still use the whole
+// ramp. Adjusting brightness or contrast by hand turns it off.
+var autoExposure = false
+
+// updateExposure moves the brightness and contrast settings towards the
+// values that center the luminance of f on the ramp.
+func updateExposure(f *frame) {
+	if !autoExposure || len(f.cells) == 0 {
+		return
+	}
+
+	var histogram [exposureBins]int
+	for _, c := range f.cells {
+		histogram[min(int(brightness(c.color)*exposureBins), exposureBins-1)]++
+	}
+	percentile := func(p float32) float32 {
+		target := int(p * float32(len(f.cells)))
+		seen := 0
+		for bin, n := range histogram {
+			seen += n
+			if seen > target {
+				return (float32(bin) + 0.5) / exposureBins
+			}
+		}
+		return 1
+	}
+	low, high := percentile(exposureLow), percentile(exposureHigh)
+
+	gain := min(max(exposureSpan/max(high-low, 1.0/exposureBins), minContrast), maxContrast)
+	offset := min(max(-((low+high)/2-0.5)*gain, -1), 1)
+	contrastGain += (gain - contrastGain) * exposureRate
+	brightnessOffset += (offset - brightnessOffset) * exposureRate
+}
+
+func exposureReadout() string {
+	if autoExposure {
+		return "Auto Exposure: on"
+	}
+	return "Auto Exposure: off"
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"image/color"
+	"time"
+)
+
+// fakeCamera returns a capture source producing a synthetic horizontal
+// gray gradient at the capture size, standing in for a webcam where none
+// is available.
+func fakeCamera() captureFunc {
+	return func(imageChan chan<- capturedImage, done <-chan struct{}) {
+		ticker := time.NewTicker(time.Second / 30)
+		defer ticker.Stop()
+
+		for number := 1; ; number++ {
+			select {
+			case <-ticker.C:
+			case <-done:
+				return
+			}
+			capturedFrames.Add(1)
+
+			cols, rows := captureSize()
+			if cols <= 0 || rows <= 0 {
+				continue
+			}
+
+			sx, sy := int(samplesX.Load()), int(samplesY.Load())
+			img := rgbaBuffer(cols*sx, rows*sy)
+			width := img.Bounds().Dx()
+			for y := 0; y < img.Bounds().Dy(); y++ {
+				for x := 0; x < width; x++ {
+					v := uint8(255 * x / max(width-1, 1))
+					img.SetRGBA(x, y, color.RGBA{v, v, v, 255})
+				}
+			}
+
+			select {
+			case imageChan <- capturedImage{img: img, cols: cols, rows: rows, meta: frameMeta{captured: time.Now(), source: "fake camera", number: number}}:
+			case <-done:
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"image/color"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// gradient is a false-color palette: luminance 0 maps to the first stop
+// and 1 to the last, with the stops evenly spaced in between.
+type gradient struct {
+	name  string
+	stops []color.RGBA
+}
+
+// at returns the gradient color for a luminance in [0, 1].
+func (g gradient) at(v float32) color.RGBA {
+	pos := min(max(v, 0), 1) * float32(len(g.stops)-1)
+	i := min(int(pos), len(g.stops)-2)
+	t := pos - float32(i)
+	a, b := g.stops[i], g.stops[i+1]
+	mix := func(x, y uint8) uint8 {
+		return clampChannel(float32(x) + (float32(y)-float32(x))*t)
+	}
+	return color.RGBA{mix(a.R, b.R), mix(a.G, b.G), mix(a.B, b.B), 255}
+}
+
+// falseColorPalettes are the gradients false-color mode cycles through.
+// Palettes from the config file or loadPalettes are appended or replace a
+// built-in of the same name.
+var falseColorPalettes = []gradient{
+	{"thermal", mustParseStops("#000000 #20008c #a0008c #ff3200 #ffc800 #ffffff")},
+	{"viridis", mustParseStops("#440154 #3b528b #21918c #5ec962 #fde725")},
+	{"magma", mustParseStops("#000004 #3b0f70 #8c2981 #de4968 #fe9f6d #fcfdbf")},
+}
+
+// falseColor is the name of the active false-color palette, or empty when
+// cells keep their own colors.
+var falseColor = ""
+
+// parseHexColor parses a #rrggbb color.
+func parseHexColor(text string) (color.RGBA, error) {
+	hex := strings.TrimPrefix(text, "#")
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if len(hex) != 6 || err != nil {
+		return color.RGBA{}, fmt.Errorf("invalid color %q, expected #rrggbb", text)
+	}
+	return color.RGBA{uint8(v >> 16), uint8(v >> 8), uint8(v), 255}, nil
+}
+
+// parseStops parses a whitespace separated list of at least two #rrggbb
+// colors.
+func parseStops(text string) ([]color.RGBA, error) {
+	var stops []color.RGBA
+	for _, field := range strings.Fields(text) {
+		c, err := parseHexColor(field)
+		if err != nil {
+			return nil, err
+		}
+		stops = append(stops, c)
+	}
+	if len(stops) < 2 {
+		return nil, fmt.Errorf("a palette needs at least two colors")
+	}
+	return stops, nil
+}
+
+func mustParseStops(text string) []color.RGBA {
+	stops, err := parseStops(text)
+	if err != nil {
+		panic(err)
+	}
+	return stops
+}
+
+// addPalette adds a palette, replacing any palette with the same name.
+func addPalette(g gradient) {
+	for i, p := range falseColorPalettes {
+		if p.name == g.name {
+			falseColorPalettes[i] = g
+			return
+		}
+	}
+	falseColorPalettes = append(falseColorPalettes, g)
+}
+
+// loadPalettes reads palettes from a file with one "name = #rrggbb ..."
+// definition per line. Blank lines and lines starting with '#' are ignored.
+func loadPalettes(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		name, text, ok := strings.Cut(line, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return fmt.Errorf("%v:%d: expected name = colors", path, n)
+		}
+		stops, err := parseStops(text)
+		if err != nil {
+			return fmt.Errorf("%v:%d: %v", path, n, err)
+		}
+		addPalette(gradient{name: name, stops: stops})
+	}
+	return scanner.Err()
+}
+
+// activePalette returns the active false-color palette.
+func activePalette() (gradient, bool) {
+	for _, p := range falseColorPalettes {
+		if p.name == falseColor {
+			return p, true
+		}
+	}
+	return gradient{}, false
+}
+
+// nextPalette returns the name of the palette following the active one,
+// with an empty name for off after the last.
+func nextPalette() string {
+	if falseColor == "" {
+		return falseColorPalettes[0].name
+	}
+	for i, p := range falseColorPalettes {
+		if p.name == falseColor && i+1 < len(falseColorPalettes) {
+			return falseColorPalettes[i+1].name
+		}
+	}
+	return ""
+}
+
+func falseColorReadout() string {
+	if falseColor == "" {
+		return "False Color: off"
+	}
+	return fmt.Sprintf("False Color: %v", falseColor)
+}
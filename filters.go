@@ -0,0 +1,146 @@
+package main
+
+import (
+	"image/color"
+	"math/rand"
+)
+
+// filter is a stage of the cell filter pipeline. Filters transform the
+// source color of each cell before it is mapped to a glyph and a terminal
+// color, so they affect both the ramp and the colors.
+type filter interface {
+	name() string
+	apply(c color.RGBA) color.RGBA
+}
+
+// filters is the active pipeline, applied in order.
+var filters []filter
+
+// applyFilters runs a color through the active pipeline.
+func applyFilters(c color.RGBA) color.RGBA {
+	for _, f := range filters {
+		c = f.apply(c)
+	}
+	return c
+}
+
+// toggleFilter removes the filter with the same name as f from the
+// pipeline, or appends f if there is none. It reports whether f is now
+// active.
+func toggleFilter(f filter) bool {
+	for i, active := range filters {
+		if active.name() == f.name() {
+			filters = append(filters[:i:i], filters[i+1:]...)
+			return false
+		}
+	}
+	filters = append(filters, f)
+	return true
+}
+
+// negativeFilter inverts colors like a film negative.
+type negativeFilter struct{}
+
+func (negativeFilter) name() string { return "negative" }
+
+func (negativeFilter) apply(c color.RGBA) color.RGBA {
+	return color.RGBA{255 - c.R, 255 - c.G, 255 - c.B, c.A}
+}
+
+// filterActive reports whether the filter with the given name is in the
+// pipeline.
+func filterActive(name string) bool {
+	for _, active := range filters {
+		if active.name() == name {
+			return true
+		}
+	}
+	return false
+}
+
+// setFilter replaces the filter with the same name as f in place, or
+// appends f if there is none.
+func setFilter(f filter) {
+	for i, active := range filters {
+		if active.name() == f.name() {
+			filters[i] = f
+			return
+		}
+	}
+	filters = append(filters, f)
+}
+
+// removeFilter removes the filter with the given name from the pipeline.
+func removeFilter(name string) {
+	for i, active := range filters {
+		if active.name() == name {
+			filters = append(filters[:i:i], filters[i+1:]...)
+			return
+		}
+	}
+}
+
+// gainFilter scales all channels, brightening the image above 1.
+type gainFilter struct {
+	gain float32
+}
+
+func (gainFilter) name() string { return "gain" }
+
+func (g gainFilter) apply(c color.RGBA) color.RGBA {
+	return color.RGBA{clampChannel(float32(c.R) * g.gain), clampChannel(float32(c.G) * g.gain), clampChannel(float32(c.B) * g.gain), c.A}
+}
+
+// monoFilter replaces a color with a single hue at the same brightness.
+type monoFilter struct {
+	hue color.RGBA
+}
+
+func (monoFilter) name() string { return "mono" }
+
+func (m monoFilter) apply(c color.RGBA) color.RGBA {
+	v := brightness(c)
+	return color.RGBA{clampChannel(float32(m.hue.R) * v), clampChannel(float32(m.hue.G) * v), clampChannel(float32(m.hue.B) * v), c.A}
+}
+
+// noiseFilter adds random brightness grain of up to amount in either
+// direction.
+type noiseFilter struct {
+	amount float32
+}
+
+func (noiseFilter) name() string { return "noise" }
+
+func (n noiseFilter) apply(c color.RGBA) color.RGBA {
+	d := (rand.Float32()*2 - 1) * n.amount * 255
+	return color.RGBA{clampChannel(float32(c.R) + d), clampChannel(float32(c.G) + d), clampChannel(float32(c.B) + d), c.A}
+}
+
+// filterPreset is a named combination of filters toggled as one.
+type filterPreset struct {
+	name    string
+	filters []filter
+}
+
+var nightVisionPreset = filterPreset{"Night Vision", []filter{
+	gainFilter{gain: 1.8},
+	monoFilter{hue: color.RGBA{80, 255, 80, 255}},
+	noiseFilter{amount: 0.06},
+}}
+
+// togglePreset removes the preset's filters if its first filter is active,
+// and adds them otherwise. It reports whether the preset is now active.
+func togglePreset(p filterPreset) bool {
+	for _, active := range filters {
+		if active.name() == p.filters[0].name() {
+			for _, f := range p.filters {
+				removeFilter(f.name())
+			}
+			return false
+		}
+	}
+	for _, f := range p.filters {
+		setFilter(f)
+	}
+	return true
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"sync/atomic"
+
+	"gocv.io/x/gocv"
+)
+
+const (
+	// charAspect is the width/height ratio of a terminal cell, used to keep
+	// proportions when the output height is not given explicitly.
+	charAspect = 0.5
+)
+
+// fitMode selects how frames are fitted to the terminal.
+type fitMode int
+
+const (
+	// fitStretch distorts the frame to fill the terminal.
+	fitStretch fitMode = iota
+	// fitFill keeps the aspect ratio and crops the center to fill the
+	// terminal.
+	fitFill
+	// fitContain keeps the aspect ratio and shows the whole frame centered,
+	// leaving the rest of the terminal empty.
+	fitContain
+	// fitLetterbox is like fitContain but paints the bars in
+	// letterboxColor.
+	fitLetterbox
+)
+
+var fitNames = []string{"stretch", "fill", "fit", "letterbox"}
+
+func (m fitMode) String() string {
+	return fitNames[m]
+}
+
+// Set implements flag.Value.
+func (m *fitMode) Set(name string) error {
+	for i, n := range fitNames {
+		if n == name {
+			*m = fitMode(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown fit mode %q", name)
+}
+
+// next returns the mode following m, wrapping around.
+func (m fitMode) next() fitMode {
+	return (m + 1) % fitMode(len(fitNames))
+}
+
+// frameFit is the fit mode used by the capture goroutine.
+var frameFit atomic.Int32
+
+// colorFlag is a color flag given as #rrggbb.
+type colorFlag color.RGBA
+
+func (c colorFlag) String() string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// Set implements flag.Value.
+func (c *colorFlag) Set(text string) error {
+	v, err := parseHexColor(text)
+	if err != nil {
+		return err
+	}
+	*c = colorFlag(v)
+	return nil
+}
+
+// letterboxColor is the color of the bars in letterbox mode.
+var letterboxColor = colorFlag{0, 0, 0, 255}
+
+// fitSize returns the largest cell size within cols x rows that keeps the
+// aspect ratio of a srcWidth x srcHeight frame.
+func fitSize(srcWidth, srcHeight, cols, rows int) (int, int) {
+	if srcWidth <= 0 || srcHeight <= 0 {
+		return cols, rows
+	}
+	aspect := float64(srcHeight) / float64(srcWidth) * charAspect
+	w, h := cols, int(float64(cols)*aspect+0.5)
+	if h > rows {
+		w, h = int(float64(rows)/aspect+0.5), rows
+	}
+	return min(max(w, 1), cols), min(max(h, 1), rows)
+}
+
+// fillRegion returns the centered part of a srcWidth x srcHeight frame
+// that has the aspect ratio of cols x rows cells.
+func fillRegion(srcWidth, srcHeight, cols, rows int) image.Rectangle {
+	w, h := srcWidth, srcHeight
+	// the source height the target aspect ratio needs at full width
+	if need := float64(rows) / charAspect / float64(cols) * float64(srcWidth); need <= float64(srcHeight) {
+		h = max(int(need), 1)
+	} else {
+		w = max(int(float64(srcHeight)*float64(cols)*charAspect/float64(rows)), 1)
+	}
+	x0, y0 := (srcWidth-w)/2, (srcHeight-h)/2
+	return image.Rect(x0, y0, x0+w, y0+h)
+}
+
+// fitFrame resizes src for an area of cols x rows cells of sx x sy samples
+// each, as mode asks. It returns the image and its size in cells, which is
+// smaller than the area for fitContain. padded holds the letterboxed image.
+func fitFrame(src gocv.Mat, small, padded *gocv.Mat, cols, rows, sx, sy int, mode fitMode) (image.Image, int, int, error) {
+	switch mode {
+	case fitFill:
+		region := src.Region(fillRegion(src.Cols(), src.Rows(), cols, rows))
+		defer region.Close()
+		img, err := resizeImage(region, small, cols*sx, rows*sy)
+		return img, cols, rows, err
+	case fitContain:
+		w, h := fitSize(src.Cols(), src.Rows(), cols, rows)
+		img, err := resizeImage(src, small, w*sx, h*sy)
+		return img, w, h, err
+	case fitLetterbox:
+		w, h := fitSize(src.Cols(), src.Rows(), cols, rows)
+		resizeMat(src, small, image.Point{X: w * sx, Y: h * sy})
+		// filter before padding so the bars do not skew filters that look
+		// at the whole image
+		applyImageFilters(small)
+		left, top := (cols-w)/2*sx, (rows-h)/2*sy
+		gocv.CopyMakeBorder(*small, padded, top, (rows-h)*sy-top, left, (cols-w)*sx-left, gocv.BorderConstant, color.RGBA(letterboxColor))
+		img, err := frameImage(padded)
+		return img, cols, rows, err
+	}
+	img, err := resizeImage(src, small, cols*sx, rows*sy)
+	return img, cols, rows, err
+}
+
+// videoFrame scales f to a cols x rows video area. In the fit mode frames
+// keep their aspect ratio, so only frames of an earlier terminal size are
+// scaled; otherwise frames are stretched to the area.
+func videoFrame(f *frame, cols, rows int) *frame {
+	if f == nil || f.width == 0 || f.height == 0 || fitMode(frameFit.Load()) != fitContain {
+		return scaleFrame(f, cols, rows)
+	}
+	scale := min(float64(cols)/float64(f.width), float64(rows)/float64(f.height))
+	return scaleFrame(f, max(int(float64(f.width)*scale), 1), max(int(float64(f.height)*scale), 1))
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"fmt"
+	"math"
+
+	"gocv.io/x/gocv"
+)
+
+// mainsFrequency is the frequency of the power grid lighting the scene.
+// Lamps flicker at twice this rate and rolling-shutter cameras turn that
+// into horizontal bands that drift through the picture.
+type mainsFrequency int
+
+const (
+	flickerOff mainsFrequency = iota
+	flicker50Hz
+	flicker60Hz
+)
+
+var flickerNames = []string{"off", "50", "60"}
+
+// flickerHertz are the grid frequencies of each setting.
+var flickerHertz = []float64{flicker50Hz: 50, flicker60Hz: 60}
+
+const (
+	// maxFlickerWindow limits how many frames the row profile is averaged
+	// over, so slow beats do not smear real brightness changes.
+	maxFlickerWindow = 12
+	// maxFlickerGain limits the correction of a single row.
+	maxFlickerGain = 2
+)
+
+// flicker is the mains frequency flicker reduction is tuned for.
+var flicker mainsFrequency
+
+// captureFPS is the frame rate reported by the camera, used to predict how
+// fast flicker bands drift.
+var captureFPS float64 = 30
+
+// flickerProfiles holds the most recent row brightness profiles. Like
+// denoiseAverage it outlives filter values.
+var flickerProfiles [][]float32
+
+func (m mainsFrequency) String() string {
+	return flickerNames[m]
+}
+
+// Set implements flag.Value.
+func (m *mainsFrequency) Set(name string) error {
+	for i, n := range flickerNames {
+		if n == name {
+			*m = mainsFrequency(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown mains frequency %q", name)
+}
+
+// next returns the setting following m, wrapping around.
+func (m mainsFrequency) next() mainsFrequency {
+	return (m + 1) % mainsFrequency(len(flickerNames))
+}
+
+// flickerWindow returns the number of frames in one period of the beat
+// between the lamp flicker and the frame rate. Averaging over exactly that
+// many frames notches the beat out. It returns 1 when the bands stand
+// still, as they then do not pulse.
+func flickerWindow(mains, fps float64) int {
+	light := 2 * mains
+	beat := math.Abs(light - math.Round(light/fps)*fps)
+	if beat < 0.5 {
+		return 1
+	}
+	return min(max(int(math.Round(fps/beat)), 2), maxFlickerWindow)
+}
+
+// flickerFilter evens out mains flicker banding. It tracks the average
+// brightness of every row over one beat period and scales each row of the
+// current frame back to that average.
+type flickerFilter struct {
+	mains mainsFrequency
+}
+
+func (flickerFilter) name() string { return "flicker reduction" }
+
+func (f flickerFilter) apply(img *gocv.Mat) {
+	window := flickerWindow(flickerHertz[f.mains], captureFPS)
+	if window < 2 {
+		return
+	}
+
+	gray := gocv.NewMat()
+	defer gray.Close()
+	if img.Channels() == 3 {
+		gocv.CvtColor(*img, &gray, gocv.ColorBGRToGray)
+	} else {
+		img.CopyTo(&gray)
+	}
+	reduced := gocv.NewMat()
+	defer reduced.Close()
+	gocv.Reduce(gray, &reduced, 1, gocv.ReduceAvg, gocv.MatTypeCV32F)
+
+	profile := make([]float32, reduced.Rows())
+	for y := range profile {
+		profile[y] = reduced.GetFloatAt(y, 0)
+	}
+	if len(flickerProfiles) > 0 && len(flickerProfiles[0]) != len(profile) {
+		flickerProfiles = nil
+	}
+	flickerProfiles = append(flickerProfiles, profile)
+	if len(flickerProfiles) > window {
+		flickerProfiles = flickerProfiles[len(flickerProfiles)-window:]
+	}
+	if len(flickerProfiles) < window {
+		return
+	}
+
+	for y, v := range profile {
+		if v <= 0 {
+			continue
+		}
+		var sum float32
+		for _, p := range flickerProfiles {
+			sum += p[y]
+		}
+		gain := min(max(sum/float32(window)/v, 1/maxFlickerGain), maxFlickerGain)
+		row := img.RowRange(y, y+1)
+		row.MultiplyFloat(gain)
+		row.Close()
+	}
+}
+
+// setFlicker tunes flicker reduction for m, turning it off for flickerOff.
+func setFlicker(m mainsFrequency) {
+	flicker = m
+	if m == flickerOff {
+		removeImageFilter(flickerFilter{}.name())
+		return
+	}
+	setImageFilter(flickerFilter{mains: m})
+}
+
+func flickerReadout() string {
+	if flicker == flickerOff {
+		return "Flicker Reduction: off"
+	}
+	return fmt.Sprintf("Flicker Reduction: %v Hz", flicker)
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/gdamore/tcell"
+)
+
+// enableFocusReporting asks the terminal to report focus changes (xterm
+// mode 1004).
+func enableFocusReporting() {
+	fmt.Fprint(oscOut, "\x1b[?1004h")
+}
+
+// disableFocusReporting turns focus reporting off again.
+func disableFocusReporting() {
+	fmt.Fprint(oscOut, "\x1b[?1004l")
+}
+
+// focusParser recognizes focus reports in the key event stream. tcell does
+// not know the ESC [ I and ESC [ O sequences, so they arrive as Alt+'['
+// followed by an 'I' or 'O' rune.
+type focusParser struct {
+	pending bool
+}
+
+// feed inspects a key event. It returns true when the event was part of a
+// focus report, in which case focused tells whether focus was gained and
+// valid whether the report is complete.
+func (p *focusParser) feed(ev *tcell.EventKey) (consumed, valid, focused bool) {
+	if ev.Key() != tcell.KeyRune {
+		p.pending = false
+		return false, false, false
+	}
+
+	if p.pending {
+		p.pending = false
+		switch ev.Rune() {
+		case 'I':
+			return true, true, true
+		case 'O':
+			return true, true, false
+		}
+		return false, false, false
+	}
+
+	if ev.Rune() == '[' && ev.Modifiers()&tcell.ModAlt != 0 {
+		p.pending = true
+		return true, false, false
+	}
+	return false, false, false
+}
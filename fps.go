@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"github.com/gdamore/tcell"
+)
+
+// fpsLayer is the z-order of the FPS counter.
+const fpsLayer = 18
+
+// capturedFrames counts the frames read from the capture device, so the
+// capture rate can be told apart from the render rate.
+var capturedFrames atomic.Int64
+
+// showFPS enables the FPS counter overlay.
+var showFPS = false
+
+// fpsCounter turns the running frame counts into per-second rates.
+type fpsCounter struct {
+	lastCaptured int64
+}
+
+// sample returns the capture rate since the previous sample, which is
+// taken once a second.
+func (c *fpsCounter) sample() int {
+	captured := capturedFrames.Load()
+	rate := int(captured - c.lastCaptured)
+	c.lastCaptured = captured
+	return rate
+}
+
+// drawFPS shows the capture and render rates in the top right corner, or
+// removes them when the counter is off.
+func drawFPS(s tcell.Screen, captureRate, renderRate int) {
+	if !showFPS {
+		overlays.remove("fps")
+		return
+	}
+	width, _ := s.Size()
+	text := fmt.Sprintf(" capture %d fps | render %d fps ", captureRate, renderRate)
+
+	sf := overlays.surface("fps", fpsLayer)
+	sf.clear()
+	sf.text(max(width-len(text), 0), 0, text, tcell.StyleDefault.Foreground(palette.message), 0.8)
+}
@@ -0,0 +1,52 @@
+//go:build !minimal
+
+package main
+
+import (
+	"image"
+	colorpalette "image/color/palette"
+	"image/draw"
+	"image/gif"
+	"io"
+	"time"
+)
+
+// gifLastFrameDelay is how long the last frame of a GIF is shown, in
+// hundredths of a second, as nothing follows it to say.
+const gifLastFrameDelay = 10
+
+// gifWriter exports frames as an animated GIF. Frames are kept until close,
+// since each one's delay is only known when the next arrives.
+type gifWriter struct {
+	w     io.Writer
+	color bool
+	anim  gif.GIF
+	last  time.Duration
+}
+
+func (gw *gifWriter) writeFrame(f *frame, at time.Duration) error {
+	img := rasterizeMat(newShownFrame(f, gw.color))
+	defer img.Close()
+	src, err := img.ToImage()
+	if err != nil {
+		return err
+	}
+
+	if n := len(gw.anim.Delay); n > 0 {
+		gw.anim.Delay[n-1] = max(int((at-gw.last)/(10*time.Millisecond)), 1)
+	}
+	gw.last = at
+
+	paletted := image.NewPaletted(src.Bounds(), colorpalette.Plan9)
+	draw.FloydSteinberg.Draw(paletted, src.Bounds(), src, image.Point{})
+	gw.anim.Image = append(gw.anim.Image, paletted)
+	gw.anim.Delay = append(gw.anim.Delay, gifLastFrameDelay)
+	return nil
+}
+
+func (gw *gifWriter) close() error {
+	if len(gw.anim.Image) == 0 {
+		return nil
+	}
+	return gif.EncodeAll(gw.w, &gw.anim)
+}
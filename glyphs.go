@@ -0,0 +1,127 @@
+package main
+
+const (
+	// glyphWidth and glyphHeight are the size of the glyph bitmaps and the
+	// number of source pixels sampled per cell in glyph matching mode.
+	glyphWidth  = 4
+	glyphHeight = 8
+)
+
+// glyphBitmap is the approximate shape of a glyph within a cell, '#' marking
+// ink and '.' background.
+type glyphBitmap struct {
+	r    rune
+	rows [glyphHeight]string
+}
+
+var glyphBitmaps = []glyphBitmap{
+	{' ', [glyphHeight]string{"....", "....", "....", "....", "....", "....", "....", "...."}},
+	{'.', [glyphHeight]string{"....", "....", "....", "....", "....", "....", ".#..", "...."}},
+	{',', [glyphHeight]string{"....", "....", "....", "....", "....", "....", ".#..", "#..."}},
+	{'\'', [glyphHeight]string{".#..", ".#..", "....", "....", "....", "....", "....", "...."}},
+	{'`', [glyphHeight]string{"#...", ".#..", "....", "....", "....", "....", "....", "...."}},
+	{'"', [glyphHeight]string{"#.#.", "#.#.", "....", "....", "....", "....", "....", "...."}},
+	{'-', [glyphHeight]string{"....", "....", "....", "....", "####", "....", "....", "...."}},
+	{'_', [glyphHeight]string{"....", "....", "....", "....", "....", "....", "....", "####"}},
+	{'=', [glyphHeight]string{"....", "....", "####", "....", "....", "####", "....", "...."}},
+	{':', [glyphHeight]string{"....", "....", ".#..", "....", "....", ".#..", "....", "...."}},
+	{'|', [glyphHeight]string{".#..", ".#..", ".#..", ".#..", ".#..", ".#..", ".#..", ".#.."}},
+	{'/', [glyphHeight]string{"...#", "...#", "..#.", "..#.", ".#..", ".#..", "#...", "#..."}},
+	{'\\', [glyphHeight]string{"#...", "#...", ".#..", ".#..", "..#.", "..#.", "...#", "...#"}},
+	{'(', [glyphHeight]string{"..#.", ".#..", "#...", "#...", "#...", "#...", ".#..", "..#."}},
+	{')', [glyphHeight]string{".#..", "..#.", "...#", "...#", "...#", "...#", "..#.", ".#.."}},
+	{'<', [glyphHeight]string{"....", "...#", "..#.", ".#..", "#...", ".#..", "..#.", "...#"}},
+	{'>', [glyphHeight]string{"....", "#...", ".#..", "..#.", "...#", "..#.", ".#..", "#..."}},
+	{'^', [glyphHeight]string{".##.", "#..#", "....", "....", "....", "....", "....", "...."}},
+	{'v', [glyphHeight]string{"....", "....", "#..#", "#..#", "#..#", ".##.", ".##.", "...."}},
+	{'o', [glyphHeight]string{"....", "....", ".##.", "#..#", "#..#", "#..#", ".##.", "...."}},
+	{'O', [glyphHeight]string{".##.", "#..#", "#..#", "#..#", "#..#", "#..#", "#..#", ".##."}},
+	{'+', [glyphHeight]string{"....", "....", ".#..", ".#..", "####", ".#..", ".#..", "...."}},
+	{'*', [glyphHeight]string{"....", "#.#.", ".#..", "###.", ".#..", "#.#.", "....", "...."}},
+	{'L', [glyphHeight]string{"#...", "#...", "#...", "#...", "#...", "#...", "#...", "####"}},
+	{'T', [glyphHeight]string{"####", ".#..", ".#..", ".#..", ".#..", ".#..", ".#..", ".#.."}},
+	{'7', [glyphHeight]string{"####", "...#", "..#.", "..#.", ".#..", ".#..", ".#..", ".#.."}},
+	{'#', [glyphHeight]string{".#.#", ".#.#", "####", ".#.#", "#.#.", "####", "#.#.", "#.#."}},
+	{'@', [glyphHeight]string{".##.", "#..#", "#.##", "#.##", "#.##", "#...", "#..#", ".##."}},
+	{'M', [glyphHeight]string{"#..#", "####", "####", "#..#", "#..#", "#..#", "#..#", "#..#"}},
+	{'W', [glyphHeight]string{"#..#", "#..#", "#..#", "#..#", "#..#", "####", "####", "#..#"}},
+}
+
+// glyphTemplate is a glyph bitmap prepared for matching: ink coverage per
+// pixel, its mean and variance, and its density relative to the densest glyph.
+type glyphTemplate struct {
+	r        rune
+	pixels   [glyphWidth * glyphHeight]float32
+	mean     float32
+	variance float32
+	density  float32
+}
+
+var glyphTemplates = buildGlyphTemplates()
+
+func buildGlyphTemplates() []glyphTemplate {
+	templates := make([]glyphTemplate, len(glyphBitmaps))
+	var maxMean float32
+	for i, g := range glyphBitmaps {
+		t := &templates[i]
+		t.r = g.r
+		for y, row := range g.rows {
+			for x := 0; x < glyphWidth; x++ {
+				if row[x] == '#' {
+					t.pixels[y*glyphWidth+x] = 1
+					t.mean++
+				}
+			}
+		}
+		t.mean /= glyphWidth * glyphHeight
+		for _, p := range t.pixels {
+			t.variance += (p - t.mean) * (p - t.mean)
+		}
+		maxMean = max(maxMean, t.mean)
+	}
+	for i := range templates {
+		templates[i].density = templates[i].mean / maxMean
+	}
+	return templates
+}
+
+// matchGlyph picks the glyph whose shape best explains a cell's block of
+// luminance values. The score combines how well the glyph's density matches
+// the block's mean brightness with how much of the block's structure is left
+// unexplained after fitting the glyph pattern to it.
+func matchGlyph(block []float32) rune {
+	var mean float32
+	for _, p := range block {
+		mean += p
+	}
+	mean /= float32(len(block))
+
+	var energy float32
+	for _, p := range block {
+		energy += (p - mean) * (p - mean)
+	}
+
+	best, bestScore := ' ', float32(-1)
+	for i := range glyphTemplates {
+		t := &glyphTemplates[i]
+
+		// least squares fit of the glyph pattern to the block's structure,
+		// restricted to positive contrast
+		var residual float32 = energy
+		if t.variance > 0 {
+			var cov float32
+			for j, p := range block {
+				cov += (p - mean) * (t.pixels[j] - t.mean)
+			}
+			if cov > 0 {
+				residual -= cov * cov / t.variance
+			}
+		}
+
+		score := residual/float32(len(block)) + (mean-t.density)*(mean-t.density)
+		if bestScore < 0 || score < bestScore {
+			best, bestScore = t.r, score
+		}
+	}
+	return best
+}
@@ -0,0 +1,21 @@
+//go:build !cuda
+
+package main
+
+import (
+	"image"
+
+	"gocv.io/x/gocv"
+)
+
+// gpuAvailable reports whether a CUDA device can take the resizing, which
+// needs a build with the cuda tag against an OpenCV with CUDA support.
+func gpuAvailable() bool {
+	return false
+}
+
+func gpuResize(src gocv.Mat, dst *gocv.Mat, size image.Point, interp gocv.InterpolationFlags, gray bool) bool {
+	return false
+}
+
+func closeGPU() {}
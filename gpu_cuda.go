@@ -0,0 +1,55 @@
+//go:build cuda
+
+package main
+
+import (
+	"image"
+
+	"gocv.io/x/gocv"
+	"gocv.io/x/gocv/cuda"
+)
+
+// gpuMats hold the frame on the GPU at each step. They are reused across
+// frames by the capture goroutine until closeGPU frees them.
+var gpuMats struct {
+	src, resized, gray cuda.GpuMat
+	open               bool
+}
+
+// gpuAvailable reports whether a CUDA device can take the resizing.
+func gpuAvailable() bool {
+	return cuda.GetCudaEnabledDeviceCount() > 0
+}
+
+// gpuResize resizes src into dst on the GPU, converting it to gray there
+// too if gray is set. It returns false for the interpolations CUDA lacks,
+// leaving the work to the CPU.
+func gpuResize(src gocv.Mat, dst *gocv.Mat, size image.Point, interp gocv.InterpolationFlags, gray bool) bool {
+	if interp == gocv.InterpolationLanczos4 {
+		return false
+	}
+	if !gpuMats.open {
+		gpuMats.src, gpuMats.resized, gpuMats.gray = cuda.NewGpuMat(), cuda.NewGpuMat(), cuda.NewGpuMat()
+		gpuMats.open = true
+	}
+	gpuMats.src.Upload(src)
+	cuda.Resize(gpuMats.src, &gpuMats.resized, size, 0, 0, cuda.InterpolationFlags(interp))
+	out := &gpuMats.resized
+	if gray && src.Channels() == 3 {
+		cuda.CvtColor(gpuMats.resized, &gpuMats.gray, gocv.ColorBGRToGray)
+		out = &gpuMats.gray
+	}
+	out.Download(dst)
+	return !dst.Empty()
+}
+
+// closeGPU frees the device memory held for frames.
+func closeGPU() {
+	if !gpuMats.open {
+		return
+	}
+	gpuMats.src.Close()
+	gpuMats.resized.Close()
+	gpuMats.gray.Close()
+	gpuMats.open = false
+}
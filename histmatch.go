@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+
+	"gocv.io/x/gocv"
+)
+
+// channelCDF is the cumulative distribution of each BGR channel of an image.
+type channelCDF [3][256]float64
+
+// computeCDF builds the per-channel cumulative distribution of an 8-bit
+// BGR Mat.
+func computeCDF(m gocv.Mat) (channelCDF, error) {
+	var cdf channelCDF
+	if m.Type() != gocv.MatTypeCV8UC3 || !m.IsContinuous() {
+		return cdf, fmt.Errorf("unsupported mat type %v", m.Type())
+	}
+	data, err := m.DataPtrUint8()
+	if err != nil {
+		return cdf, err
+	}
+
+	for i := 0; i+2 < len(data); i += 3 {
+		cdf[0][data[i]]++
+		cdf[1][data[i+1]]++
+		cdf[2][data[i+2]]++
+	}
+	total := float64(len(data) / 3)
+	for c := range cdf {
+		sum := 0.0
+		for v := range cdf[c] {
+			sum += cdf[c][v]
+			cdf[c][v] = sum / total
+		}
+	}
+	return cdf, nil
+}
+
+// matchHistogram remaps m in place so that each channel's distribution
+// follows ref, keeping the look consistent across cameras.
+func matchHistogram(m *gocv.Mat, ref channelCDF) error {
+	src, err := computeCDF(*m)
+	if err != nil {
+		return err
+	}
+
+	var lut [3][256]uint8
+	for c := range lut {
+		target := 0
+		for v := range lut[c] {
+			for target < 255 && ref[c][target] < src[c][v] {
+				target++
+			}
+			lut[c][v] = uint8(target)
+		}
+	}
+
+	data, err := m.DataPtrUint8()
+	if err != nil {
+		return err
+	}
+	for i := 0; i+2 < len(data); i += 3 {
+		data[i] = lut[0][data[i]]
+		data[i+1] = lut[1][data[i+1]]
+		data[i+2] = lut[2][data[i+2]]
+	}
+	return nil
+}
+
+// loadReferenceCDF reads the histogram reference image at path.
+func loadReferenceCDF(path string) (channelCDF, error) {
+	img := gocv.IMRead(path, gocv.IMReadColor)
+	defer img.Close()
+	if img.Empty() {
+		return channelCDF{}, fmt.Errorf("could not read image %v", path)
+	}
+	return computeCDF(img)
+}
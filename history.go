@@ -0,0 +1,33 @@
+package main
+
+// frameHistorySize is the number of recent frames kept for stepping
+// through while paused.
+const frameHistorySize = 64
+
+// frameRing holds the most recent frames, oldest first, and the position
+// of the frame shown while paused.
+type frameRing struct {
+	frames []*frame
+	pos    int
+}
+
+// push adds a frame, dropping the oldest one when the ring is full, and
+// makes it the current frame.
+func (h *frameRing) push(f *frame) {
+	if len(h.frames) == frameHistorySize {
+		copy(h.frames, h.frames[1:])
+		h.frames = h.frames[:len(h.frames)-1]
+	}
+	h.frames = append(h.frames, f)
+	h.pos = len(h.frames) - 1
+}
+
+// step moves the current frame by n frames, stopping at either end, and
+// returns it. It returns nil when the ring is empty.
+func (h *frameRing) step(n int) *frame {
+	if len(h.frames) == 0 {
+		return nil
+	}
+	h.pos = min(max(h.pos+n, 0), len(h.frames)-1)
+	return h.frames[h.pos]
+}
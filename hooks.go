@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+const (
+	// motionCellDelta is how much a cell's brightness must change between
+	// frames to count as moving.
+	motionCellDelta = 0.15
+	// motionThreshold is the fraction of moving cells that fires on_motion.
+	motionThreshold = 0.05
+	// motionCooldown is the minimum time between on_motion hooks.
+	motionCooldown = 5 * time.Second
+)
+
+// hookRunner runs the user commands configured in the [hooks] section of
+// the config file, e.g. on_screenshot = "notify.sh". Commands run through
+// the shell in the background, with the event context in ASCII_WEBCAM_*
+// environment variables.
+type hookRunner struct {
+	commands map[string]string
+	// failures reports hooks that could not run or exited with an error.
+	failures chan error
+}
+
+var hooks = newHookRunner()
+
+func newHookRunner() *hookRunner {
+	return &hookRunner{commands: map[string]string{}, failures: make(chan error, 16)}
+}
+
+// configure takes the hook commands from the [hooks] section of cfg.
+func (h *hookRunner) configure(cfg *config) error {
+	for name, v := range cfg.section("hooks") {
+		command, ok := v.(string)
+		if !ok || !strings.HasPrefix(name, "on_") {
+			return fmt.Errorf("%v: hooks must be on_<event> = \"command\", got %v", cfg.path, name)
+		}
+		h.commands[name] = command
+	}
+	return nil
+}
+
+// configured reports whether there is a hook for event.
+func (h *hookRunner) configured(event string) bool {
+	_, ok := h.commands["on_"+event]
+	return ok
+}
+
+// fire runs the hook for event, if one is configured, without waiting for
+// it. context is added to the environment as ASCII_WEBCAM_<KEY>=value.
+func (h *hookRunner) fire(event string, context map[string]string) {
+	name := "on_" + event
+	command, ok := h.commands[name]
+	if !ok {
+		return
+	}
+
+	cmd := exec.Command("sh", "-c", command)
+	cmd.Env = append(os.Environ(),
+		"ASCII_WEBCAM_EVENT="+event,
+		"ASCII_WEBCAM_TIME="+time.Now().Format(time.RFC3339),
+	)
+	for k, v := range context {
+		cmd.Env = append(cmd.Env, "ASCII_WEBCAM_"+strings.ToUpper(k)+"="+v)
+	}
+	go func() {
+		if err := cmd.Run(); err != nil {
+			select {
+			case h.failures <- fmt.Errorf("hook %v: %v", name, err):
+			default:
+			}
+		}
+	}()
+}
+
+// motionDetector decides when enough of the picture changed to report
+// motion, at most once per cooldown.
+type motionDetector struct {
+	lastFired time.Time
+}
+
+// feed takes the change from the previous frame and reports whether it
+// counts as motion.
+func (m *motionDetector) feed(change float32) bool {
+	if change < motionThreshold || time.Since(m.lastFired) < motionCooldown {
+		return false
+	}
+	m.lastFired = time.Now()
+	return true
+}
@@ -0,0 +1,87 @@
+//go:build !minimal
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"image/color"
+	"io"
+	"strings"
+	"time"
+)
+
+func init() {
+	screenshotFormats["html"] = writeHTMLScreenshot
+}
+
+// writeHTMLScreenshot writes a screenshot as a standalone HTML page.
+func writeHTMLScreenshot(w io.Writer, sf *shownFrame) (string, error) {
+	return ".html", writeHTML(w, sf)
+}
+
+// writeHTMLHeader starts a standalone HTML page for <pre> frames. Lines
+// are as tall as the font and cells keep their background, so the page
+// looks like the terminal did.
+func writeHTMLHeader(w *bufio.Writer) {
+	w.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>ascii-webcam</title>\n")
+	fmt.Fprintf(w, "<style>body{background:%s;color:%s}pre{font-family:monospace;line-height:1}</style>\n",
+		hexColor(exportBackground), hexColor(exportForeground))
+	w.WriteString("</head>\n<body>\n")
+}
+
+func writeHTMLFooter(w *bufio.Writer) {
+	w.WriteString("</body>\n</html>\n")
+}
+
+// writeHTMLFrame writes a frame as a <pre> block, with a span for every
+// run of cells in the same colors.
+func writeHTMLFrame(w *bufio.Writer, sf *shownFrame) error {
+	fmt.Fprintf(w, "<pre data-source=\"%s\" data-captured=\"%s\" data-scene=\"%d\">",
+		html.EscapeString(sf.meta.source), sf.meta.captured.Format(time.RFC3339Nano), sf.meta.scene)
+	for y := 0; y < sf.height; y++ {
+		for x := 0; x < sf.width; {
+			c := sf.at(x, y)
+			run := x + 1
+			for run < sf.width && sf.at(run, y).fg == c.fg && sf.at(run, y).bg == c.bg {
+				run++
+			}
+			var sb strings.Builder
+			for i := x; i < run; i++ {
+				sb.WriteRune(sf.at(i, y).r)
+			}
+			if style := htmlStyle(c.fg, c.bg); style != "" {
+				fmt.Fprintf(w, "<span style=\"%s\">%s</span>", style, html.EscapeString(sb.String()))
+			} else {
+				w.WriteString(html.EscapeString(sb.String()))
+			}
+			x = run
+		}
+		w.WriteByte('\n')
+	}
+	_, err := w.WriteString("</pre>\n")
+	return err
+}
+
+// htmlStyle returns the inline style for cells in the given colors, empty
+// for the page's default colors.
+func htmlStyle(fg, bg color.RGBA) string {
+	var styles []string
+	if fg.A > 0 {
+		styles = append(styles, "color:"+hexColor(fg))
+	}
+	if bg.A > 0 {
+		styles = append(styles, "background:"+hexColor(bg))
+	}
+	return strings.Join(styles, ";")
+}
+
+// writeHTML writes a single frame as a standalone HTML page.
+func writeHTML(w io.Writer, sf *shownFrame) error {
+	bw := bufio.NewWriter(w)
+	writeHTMLHeader(bw)
+	writeHTMLFrame(bw, sf)
+	writeHTMLFooter(bw)
+	return bw.Flush()
+}
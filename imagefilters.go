@@ -0,0 +1,127 @@
+package main
+
+import (
+	"sync"
+
+	"gocv.io/x/gocv"
+)
+
+// imageFilter is a stage of the image filter pipeline, which runs OpenCV
+// operations on the resized BGR image before it is converted to cells.
+// Unlike the cell filters it can look at neighbouring pixels.
+type imageFilter interface {
+	name() string
+	// apply filters img in place.
+	apply(img *gocv.Mat)
+}
+
+// imageFilters is the active image pipeline, applied in order. It is shared
+// between the UI and the capture goroutine.
+var imageFilters struct {
+	mu      sync.Mutex
+	filters []imageFilter
+}
+
+// applyImageFilters runs img through the active image pipeline.
+func applyImageFilters(img *gocv.Mat) {
+	imageFilters.mu.Lock()
+	defer imageFilters.mu.Unlock()
+	for _, f := range imageFilters.filters {
+		if img.Empty() {
+			return
+		}
+		f.apply(img)
+	}
+}
+
+// imageFiltersActive reports whether the image pipeline has any filter.
+func imageFiltersActive() bool {
+	imageFilters.mu.Lock()
+	defer imageFilters.mu.Unlock()
+	return len(imageFilters.filters) > 0
+}
+
+// toggleImageFilter removes the image filter with the same name as f, or
+// appends f if there is none. It reports whether f is now active.
+func toggleImageFilter(f imageFilter) bool {
+	imageFilters.mu.Lock()
+	defer imageFilters.mu.Unlock()
+	for i, active := range imageFilters.filters {
+		if active.name() == f.name() {
+			imageFilters.filters = append(imageFilters.filters[:i:i], imageFilters.filters[i+1:]...)
+			return false
+		}
+	}
+	imageFilters.filters = append(imageFilters.filters, f)
+	return true
+}
+
+// setImageFilter replaces the image filter with the same name as f in
+// place, or appends f if there is none.
+func setImageFilter(f imageFilter) {
+	imageFilters.mu.Lock()
+	defer imageFilters.mu.Unlock()
+	for i, active := range imageFilters.filters {
+		if active.name() == f.name() {
+			imageFilters.filters[i] = f
+			return
+		}
+	}
+	imageFilters.filters = append(imageFilters.filters, f)
+}
+
+// removeImageFilter removes the image filter with the given name.
+func removeImageFilter(name string) {
+	imageFilters.mu.Lock()
+	defer imageFilters.mu.Unlock()
+	for i, active := range imageFilters.filters {
+		if active.name() == name {
+			imageFilters.filters = append(imageFilters.filters[:i:i], imageFilters.filters[i+1:]...)
+			return
+		}
+	}
+}
+
+// imageFilterActive reports whether an image filter with the given name is
+// in the pipeline.
+func imageFilterActive(name string) bool {
+	imageFilters.mu.Lock()
+	defer imageFilters.mu.Unlock()
+	for _, active := range imageFilters.filters {
+		if active.name() == name {
+			return true
+		}
+	}
+	return false
+}
+
+// resetImageFilters clears the image pipeline.
+func resetImageFilters() {
+	imageFilters.mu.Lock()
+	defer imageFilters.mu.Unlock()
+	imageFilters.filters = nil
+}
+
+// cartoonFilter flattens colors with a bilateral filter and draws dark
+// outlines from an adaptive threshold, for a comic look.
+type cartoonFilter struct{}
+
+func (cartoonFilter) name() string { return "cartoon" }
+
+func (cartoonFilter) apply(img *gocv.Mat) {
+	smooth := gocv.NewMat()
+	defer smooth.Close()
+	gray := gocv.NewMat()
+	defer gray.Close()
+	edges := gocv.NewMat()
+	defer edges.Close()
+
+	gocv.BilateralFilter(*img, &smooth, 9, 75, 75)
+
+	gocv.CvtColor(*img, &gray, gocv.ColorBGRToGray)
+	gocv.MedianBlur(gray, &edges, 5)
+	gocv.AdaptiveThreshold(edges, &gray, 255, gocv.AdaptiveThresholdMean, gocv.ThresholdBinary, 9, 2)
+	gocv.CvtColor(gray, &edges, gocv.ColorGrayToBGR)
+
+	gocv.BitwiseAnd(smooth, edges, img)
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"sync/atomic"
+
+	"gocv.io/x/gocv"
+)
+
+// interpolationMode selects how frames are resampled to the cell grid.
+type interpolationMode int
+
+const (
+	interpolationNearest interpolationMode = iota
+	interpolationLinear
+	// interpolationArea averages the source pixels under each target
+	// pixel, which looks best for heavy downscales.
+	interpolationArea
+	interpolationCubic
+	interpolationLanczos
+)
+
+var interpolationNames = []string{"nearest", "linear", "area", "cubic", "lanczos"}
+
+var interpolationFlags = []gocv.InterpolationFlags{
+	interpolationNearest: gocv.InterpolationNearestNeighbor,
+	interpolationLinear:  gocv.InterpolationLinear,
+	interpolationArea:    gocv.InterpolationArea,
+	interpolationCubic:   gocv.InterpolationCubic,
+	interpolationLanczos: gocv.InterpolationLanczos4,
+}
+
+func (m interpolationMode) String() string {
+	return interpolationNames[m]
+}
+
+// Set implements flag.Value.
+func (m *interpolationMode) Set(name string) error {
+	for i, n := range interpolationNames {
+		if n == name {
+			*m = interpolationMode(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown interpolation %q", name)
+}
+
+// next returns the mode following m, wrapping around.
+func (m interpolationMode) next() interpolationMode {
+	return (m + 1) % interpolationMode(len(interpolationNames))
+}
+
+// interpolation is the resize interpolation. It is set by the UI and read
+// by the capture goroutine.
+var interpolation atomic.Int32
+
+func init() {
+	interpolation.Store(int32(interpolationLinear))
+}
+
+// resizeFlag returns the OpenCV flag for the current interpolation.
+func resizeFlag() gocv.InterpolationFlags {
+	return interpolationFlags[interpolation.Load()]
+}
+
+// useGPU makes frames resize on the GPU where one is available.
+var useGPU bool
+
+// resizeMat scales src to size with the current interpolation, on the GPU
+// if enabled and able, and on the CPU otherwise.
+func resizeMat(src gocv.Mat, dst *gocv.Mat, size image.Point) {
+	if useGPU && gpuResize(src, dst, size, resizeFlag(), false) {
+		return
+	}
+	gocv.Resize(src, dst, size, 0, 0, resizeFlag())
+}
@@ -0,0 +1,83 @@
+//go:build !minimal
+
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+func init() {
+	screenshotFormats["json"] = writeJSONScreenshot
+}
+
+// writeJSONScreenshot writes a screenshot as JSON.
+func writeJSONScreenshot(w io.Writer, sf *shownFrame) (string, error) {
+	return ".json", writeJSON(w, sf)
+}
+
+// jsonFrame is the JSON form of a frame: its cells row by row and what is
+// known about how it was made.
+type jsonFrame struct {
+	Width    int            `json:"width"`
+	Height   int            `json:"height"`
+	Captured time.Time      `json:"captured"`
+	Source   string         `json:"source"`
+	Number   int            `json:"number"`
+	Scene    int            `json:"scene"`
+	Settings sourceSettings `json:"settings"`
+	Rows     [][]jsonCell   `json:"rows"`
+}
+
+// jsonCell is a cell with its colors as #rrggbb, left out for the
+// terminal's default colors.
+type jsonCell struct {
+	Rune string `json:"rune"`
+	FG   string `json:"fg,omitempty"`
+	BG   string `json:"bg,omitempty"`
+}
+
+// writeJSON writes a frame as a single line of JSON, so a sequence of
+// frames is a JSON Lines file.
+func writeJSON(w io.Writer, sf *shownFrame) error {
+	jf := jsonFrame{
+		Width:    sf.width,
+		Height:   sf.height,
+		Captured: sf.meta.captured,
+		Source:   sf.meta.source,
+		Number:   sf.meta.number,
+		Scene:    sf.meta.scene,
+		Settings: sf.settings,
+		Rows:     make([][]jsonCell, sf.height),
+	}
+	for y := range jf.Rows {
+		jf.Rows[y] = make([]jsonCell, sf.width)
+		for x := range jf.Rows[y] {
+			c := sf.at(x, y)
+			jc := jsonCell{Rune: string(c.r)}
+			if c.fg.A > 0 {
+				jc.FG = hexColor(c.fg)
+			}
+			if c.bg.A > 0 {
+				jc.BG = hexColor(c.bg)
+			}
+			jf.Rows[y][x] = jc
+		}
+	}
+	return json.NewEncoder(w).Encode(jf)
+}
+
+// jsonWriter exports frames as JSON Lines, one frame per line.
+type jsonWriter struct {
+	w     io.Writer
+	color bool
+}
+
+func (jw *jsonWriter) writeFrame(f *frame, at time.Duration) error {
+	return writeJSON(jw.w, newShownFrame(f, jw.color))
+}
+
+func (jw *jsonWriter) close() error {
+	return nil
+}
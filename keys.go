@@ -0,0 +1,198 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/gdamore/tcell"
+)
+
+// keyAction is a viewer command bound to a single printable key. name is
+// how the [keys] section of the config file refers to it.
+type keyAction struct {
+	name string
+	ev   event
+	key  rune
+	help string
+}
+
+// keyActions lists the rebindable commands with their default keys. The
+// arrow keys, Esc, PgUp and PgDn keep fixed meanings, and ':', the leader
+// key and the digits 1 to 9 are reserved for the prompt, chords and
+// presets.
+var keyActions = []keyAction{
+	{"quit", quit, 'q', "quit"},
+	{"help", helpToggle, '?', "show these keys"},
+	{"pause", pauseToggle, 'P', "pause"},
+	{"step-forward", stepForward, '.', "next frame while paused"},
+	{"step-backward", stepBackward, ',', "previous frame while paused"},
+	{"log-pane", logPaneToggle, '`', "log pane"},
+	{"fps", fpsToggle, 'D', "FPS counter"},
+	{"resources", resourcesToggle, 'G', "CPU and memory usage"},
+	{"menu", menuToggle, 'm', "settings menu"},
+	{"screenshot", screenshot, 's', "screenshot"},
+	{"record", recordToggle, 'W', "record to a .cast file"},
+	{"color", colorToggle, 'c', "color"},
+	{"pixel", pixelToggle, 'p', "pixel mode"},
+	{"edges", edgesToggle, 'e', "edges"},
+	{"glyphs", glyphToggle, 'g', "glyph matching"},
+	{"invert", invertToggle, 'i', "invert"},
+	{"dither", ditherCycle, 'd', "dithering"},
+	{"charset", charsetCycle, 'X', "charset"},
+	{"simulate-cvd", cvdCycle, 'v', "color blindness simulation"},
+	{"tint", tintCycle, 'U', "tint"},
+	{"false-color", falseColorCycle, 'f', "false color"},
+	{"rain", rainToggle, 'x', "matrix rain"},
+	{"keystone", keystoneSelect, 'k', "keystone corner"},
+	{"keystone-reset", keystoneReset, 'K', "reset keystone"},
+	{"crop-clear", cropClear, 'C', "clear crop"},
+	{"zoom-in", zoomIn, 'I', "zoom in"},
+	{"zoom-out", zoomOut, 'O', "zoom out"},
+	{"brightness-up", increaseBrightness, '+', "brightness up"},
+	{"brightness-down", decreaseBrightness, '-', "brightness down"},
+	{"contrast-up", increaseContrast, ']', "contrast up"},
+	{"contrast-down", decreaseContrast, '[', "contrast down"},
+	{"auto-exposure", exposureToggle, 'E', "auto exposure"},
+	{"saturation-up", increaseSaturation, '>', "saturation up"},
+	{"saturation-down", decreaseSaturation, '<', "saturation down"},
+	{"hue-left", hueLeft, 'h', "hue left"},
+	{"hue-right", hueRight, 'H', "hue right"},
+	{"hue-cycle", hueCycleToggle, 'u', "hue cycling"},
+	{"warmer", warmer, 't', "warmer"},
+	{"cooler", cooler, 'T', "cooler"},
+	{"negative", negativeToggle, 'n', "negative"},
+	{"night-vision", nightVisionToggle, 'N', "night vision"},
+	{"cartoon", cartoonToggle, 'o', "cartoon"},
+	{"local-contrast", claheToggle, 'l', "local contrast"},
+	{"white-balance", whiteBalanceToggle, 'w', "white balance"},
+	{"onion-skin-up", increaseOnionSkin, 'j', "more onion skin"},
+	{"onion-skin-down", decreaseOnionSkin, 'J', "less onion skin"},
+	{"long-exposure", longExposureCycle, 'y', "long exposure"},
+	{"long-exposure-reset", longExposureReset, 'Y', "restart long exposure"},
+	{"stack", stackCycle, 'S', "stacking"},
+	{"flicker", flickerCycle, 'F', "flicker reduction"},
+	{"sharpen-up", increaseSharpen, 'a', "sharpen more"},
+	{"sharpen-down", decreaseSharpen, 'A', "sharpen less"},
+	{"denoise-up", increaseDenoise, 'z', "denoise more"},
+	{"denoise-down", decreaseDenoise, 'Z', "denoise less"},
+	{"rotate", rotateCycle, 'r', "rotate"},
+	{"interlaced", interlacedToggle, 'L', "interlaced conversion"},
+	{"interpolation", interpolationCycle, 'R', "interpolation"},
+	{"fit", fitCycle, 'B', "fit mode"},
+	{"mirror", mirrorToggle, 'M', "mirror"},
+	{"flip", flipToggle, 'V', "flip"},
+	{"threshold", thresholdToggle, 'b', "threshold"},
+	{"threshold-up", increaseThreshold, '}', "threshold up"},
+	{"threshold-down", decreaseThreshold, '{', "threshold down"},
+	{"posterize-up", increasePosterize, ')', "more posterize levels"},
+	{"posterize-down", decreasePosterize, '(', "fewer posterize levels"},
+}
+
+// keyBindings maps a printable key to the event it sends.
+var keyBindings = map[rune]event{}
+
+func init() {
+	for _, a := range keyActions {
+		keyBindings[a.key] = a.ev
+	}
+}
+
+// bindKeys rebinds the actions named in the [keys] section of cfg, each
+// to a single character, and rejects keys bound to two actions.
+func bindKeys(cfg *config) error {
+	keys := cfg.section("keys")
+	for name := range keys {
+		if !isKeyAction(name) {
+			return fmt.Errorf("%v: unknown key action %v", cfg.path, name)
+		}
+	}
+
+	bound := map[rune]string{}
+	for i, a := range keyActions {
+		if v, ok := keys[a.name]; ok {
+			text, _ := v.(string)
+			r := []rune(text)
+			if len(r) != 1 {
+				return fmt.Errorf("%v: key for %v must be a single character", cfg.path, a.name)
+			}
+			if r[0] == ':' || r[0] == leaderKey || (r[0] >= '1' && r[0] <= '9') {
+				return fmt.Errorf("%v: key %q for %v is reserved", cfg.path, r[0], a.name)
+			}
+			keyActions[i].key = r[0]
+		}
+		if other, ok := bound[keyActions[i].key]; ok {
+			return fmt.Errorf("%v: key %q is bound to both %v and %v", cfg.path, keyActions[i].key, other, a.name)
+		}
+		bound[keyActions[i].key] = a.name
+	}
+
+	clear(keyBindings)
+	for _, a := range keyActions {
+		keyBindings[a.key] = a.ev
+	}
+	return nil
+}
+
+// isKeyAction reports whether name is a key action.
+func isKeyAction(name string) bool {
+	for _, a := range keyActions {
+		if a.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// keyFor returns the key bound to ev, for messages telling the user what
+// to press.
+func keyFor(ev event) string {
+	for _, a := range keyActions {
+		if a.ev == ev {
+			return keyName(a.key)
+		}
+	}
+	return "?"
+}
+
+// keyName describes a key the way it is shown in help texts.
+func keyName(r rune) string {
+	if r == '`' {
+		return "backtick"
+	}
+	return string(r)
+}
+
+// helpLayer is the z-order of the key help, above the settings menu.
+const helpLayer = 26
+
+// drawHelp shows every key binding in columns filling the screen above
+// the status bar.
+func drawHelp(s tcell.Screen) {
+	width, height := s.Size()
+	rows := max(height-logHeight-1, 1)
+
+	entries := make([]string, len(keyActions))
+	entryWidth := 0
+	for i, a := range keyActions {
+		entries[i] = fmt.Sprintf(" %-8s %v ", keyName(a.key), a.help)
+		entryWidth = max(entryWidth, len([]rune(entries[i])))
+	}
+
+	sf := overlays.surface("help", helpLayer)
+	sf.clear()
+	base := tcell.StyleDefault.Background(tcell.ColorBlack).Foreground(tcell.ColorWhite)
+	columns := (len(entries) + rows - 1) / rows
+	title := fmt.Sprintf(" Keys  (%v to close, arrows pan, PgUp/PgDn scroll the log) ", keyFor(helpToggle))
+	sf.text(0, 0, fmt.Sprintf("%-*s", min(columns*entryWidth, width), title), base.Foreground(palette.highlight), 0.9)
+	for i, entry := range entries {
+		x, y := i/rows*entryWidth, 1+i%rows
+		if x+entryWidth > width {
+			break
+		}
+		sf.text(x, y, fmt.Sprintf("%-*s", entryWidth, entry), base, 0.9)
+	}
+}
+
+// closeHelp hides the key help.
+func closeHelp() {
+	overlays.remove("help")
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"image"
+	"sync"
+
+	"gocv.io/x/gocv"
+)
+
+const (
+	// keystoneStep is how far a corner moves per key press, as a fraction
+	// of the frame size.
+	keystoneStep = 0.01
+)
+
+var keystoneCornerNames = [4]string{"top-left", "top-right", "bottom-right", "bottom-left"}
+
+// keystone holds manual perspective correction: for each frame corner,
+// the offset of the source point that should be mapped onto it. It is
+// adjusted by the UI and read by the capture goroutine.
+type keystone struct {
+	mu       sync.Mutex
+	offsets  [4][2]float64
+	selected int
+}
+
+var perspective = &keystone{selected: -1}
+
+// selectNext cycles the corner being adjusted, ending with none selected.
+// It returns the name of the selected corner, or "" when none is.
+func (k *keystone) selectNext() string {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.selected++
+	if k.selected >= len(k.offsets) {
+		k.selected = -1
+		return ""
+	}
+	return keystoneCornerNames[k.selected]
+}
+
+// active reports whether a corner is selected for adjustment.
+func (k *keystone) active() bool {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.selected >= 0
+}
+
+// nudge moves the selected corner by dx, dy steps.
+func (k *keystone) nudge(dx, dy int) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if k.selected < 0 {
+		return
+	}
+	o := &k.offsets[k.selected]
+	o[0] = min(max(o[0]+float64(dx)*keystoneStep, -0.5), 0.5)
+	o[1] = min(max(o[1]+float64(dy)*keystoneStep, -0.5), 0.5)
+}
+
+// reset removes all perspective correction.
+func (k *keystone) reset() {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.offsets = [4][2]float64{}
+}
+
+// apply warps src into dst so that the adjusted corners become the frame
+// corners. It returns false, leaving dst untouched, when no correction is set.
+func (k *keystone) apply(src gocv.Mat, dst *gocv.Mat) bool {
+	k.mu.Lock()
+	offsets := k.offsets
+	k.mu.Unlock()
+
+	if offsets == [4][2]float64{} || src.Empty() {
+		return false
+	}
+
+	w, h := src.Cols(), src.Rows()
+	corners := [4]image.Point{{0, 0}, {w - 1, 0}, {w - 1, h - 1}, {0, h - 1}}
+
+	srcPoints := make([]image.Point, len(corners))
+	for i, c := range corners {
+		srcPoints[i] = image.Point{
+			X: c.X + int(offsets[i][0]*float64(w)),
+			Y: c.Y + int(offsets[i][1]*float64(h)),
+		}
+	}
+
+	srcVector := gocv.NewPointVectorFromPoints(srcPoints)
+	defer srcVector.Close()
+	dstVector := gocv.NewPointVectorFromPoints(corners[:])
+	defer dstVector.Close()
+
+	m := gocv.GetPerspectiveTransform(srcVector, dstVector)
+	defer m.Close()
+
+	gocv.WarpPerspective(src, dst, m, image.Point{X: w, Y: h})
+	return true
+}
@@ -0,0 +1,49 @@
+package main
+
+import "sync/atomic"
+
+// keepLatest relays images from in to out without ever making the capture
+// wait on the viewer. While the viewer is busy only the newest frame is
+// kept and older ones are dropped, so what is drawn is as recent as
+// possible instead of lagging behind by a queue. Capture problems are
+// never dropped. While hold is set and a frame is waiting, in is not read,
+// so the capture blocks as it would on the viewer.
+func keepLatest(in <-chan capturedImage, out chan<- capturedImage, hold *atomic.Bool, done <-chan struct{}) {
+	var problems []capturedImage
+	var latest *capturedImage
+	for {
+		var next capturedImage
+		var send chan<- capturedImage
+		if len(problems) > 0 {
+			next, send = problems[0], out
+		} else if latest != nil {
+			next, send = *latest, out
+		}
+		input := in
+		if hold.Load() && latest != nil {
+			input = nil
+		}
+
+		select {
+		case img := <-input:
+			if img.problem != "" {
+				problems = append(problems, img)
+			} else {
+				if latest != nil && latest.img != nil {
+					diag.Debug("dropping stale frame")
+					metrics.dropped.Add(1)
+					releaseImage(latest.img)
+				}
+				latest = &img
+			}
+		case send <- next:
+			if len(problems) > 0 {
+				problems = problems[1:]
+			} else {
+				latest = nil
+			}
+		case <-done:
+			return
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+)
+
+const (
+	brightnessStep = 0.05
+	contrastStep   = 0.1
+	minContrast    = 0.1
+	maxContrast    = 5
+)
+
+var (
+	// brightnessOffset is added to luminance before mapping, in [-1, 1].
+	brightnessOffset float32 = 0
+	// contrastGain scales luminance around mid gray.
+	contrastGain float32 = 1
+)
+
+// adjustLevels applies the brightness and contrast settings to a luminance
+// value, keeping it in [0, 1].
+func adjustLevels(v float32) float32 {
+	v = (v-0.5)*contrastGain + 0.5 + brightnessOffset
+	return min(max(v, 0), 1)
+}
+
+// changeBrightness moves the brightness offset by steps, overriding
+// auto-exposure.
+func changeBrightness(steps int) {
+	autoExposure = false
+	brightnessOffset = min(max(brightnessOffset+float32(steps)*brightnessStep, -1), 1)
+}
+
+// changeContrast moves the contrast gain by steps, overriding
+// auto-exposure.
+func changeContrast(steps int) {
+	autoExposure = false
+	contrastGain = min(max(contrastGain+float32(steps)*contrastStep, minContrast), maxContrast)
+}
+
+// levelsReadout describes the current brightness and contrast settings.
+func levelsReadout() string {
+	return fmt.Sprintf("Brightness: %+.2f  Contrast: %.1f", brightnessOffset, contrastGain)
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"flag"
+	"time"
+)
+
+// linkQueueSize bounds how many frames the simulated link holds in flight.
+const linkQueueSize = 256
+
+// The simulated link is a developer aid for testing frame dropping and
+// streaming on a slow connection without one.
+var (
+	simLatency   = flag.Duration("sim-latency", 0, "developer mode: delay every rendered and served frame by this long")
+	simBandwidth = flag.Int("sim-bandwidth", 0, "developer mode: limit rendering and serving to this many bytes per second (0 is unlimited)")
+)
+
+// transferTime is how long n bytes take on the simulated link.
+func transferTime(n int) time.Duration {
+	if *simBandwidth <= 0 {
+		return 0
+	}
+	return time.Duration(float64(n) / float64(*simBandwidth) * float64(time.Second))
+}
+
+// simulateLink wraps capture so that its images arrive after the simulated
+// latency, and are dropped while the simulated bandwidth is used up by the
+// previous frame. A frame is counted as one byte per cell.
+func simulateLink(capture captureFunc) captureFunc {
+	if *simLatency <= 0 && *simBandwidth <= 0 {
+		return capture
+	}
+
+	return func(imageChan chan<- capturedImage, done <-chan struct{}) {
+		type stamped struct {
+			img capturedImage
+			at  time.Time
+		}
+
+		source := make(chan capturedImage)
+		queue := make(chan stamped, linkQueueSize)
+		captureDone := make(chan struct{})
+		go func() {
+			capture(source, done)
+			close(captureDone)
+		}()
+		go func() {
+			for {
+				select {
+				case img := <-source:
+					select {
+					case queue <- stamped{img, time.Now()}:
+					default:
+						// link saturated, drop the frame
+						releaseImage(img.img)
+					}
+				case <-done:
+					return
+				}
+			}
+		}()
+
+		var busyUntil time.Time
+		for {
+			select {
+			case next := <-queue:
+				select {
+				case <-time.After(time.Until(next.at.Add(*simLatency))):
+				case <-done:
+					<-captureDone
+					return
+				}
+
+				if next.img.img != nil {
+					now := time.Now()
+					if now.Before(busyUntil) {
+						releaseImage(next.img.img)
+						continue
+					}
+					busyUntil = now.Add(transferTime(next.img.cols * next.img.rows))
+				}
+
+				select {
+				case imageChan <- next.img:
+				case <-done:
+					<-captureDone
+					return
+				}
+			case <-done:
+				<-captureDone
+				return
+			}
+		}
+	}
+}
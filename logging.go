@@ -0,0 +1,28 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+)
+
+// diag logs diagnostics such as camera errors and dropped frames. The
+// terminal belongs to the UI, so it only writes to a file given with
+// -log-file and discards everything otherwise.
+var diag = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// openLogFile sends diagnostics at level and above to the file at path.
+// The returned file must be closed on exit.
+func openLogFile(path, level string) (*os.File, error) {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("invalid log level %q, expected debug, info, warn or error", level)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	diag = slog.New(slog.NewTextHandler(f, &slog.HandlerOptions{Level: l}))
+	return f, nil
+}
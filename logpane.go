@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gdamore/tcell"
+)
+
+const (
+	// logPaneLayer is the z-order of the log pane, under the other
+	// overlays.
+	logPaneLayer = 11
+	// logHistorySize is the number of messages kept for scrollback.
+	logHistorySize = 500
+)
+
+// logPane is a collapsible pane above the status bar listing recent log
+// messages, backed by a ring buffer.
+type logPane struct {
+	messages []string
+	next     int
+	open     bool
+	height   int
+	// scroll is how many messages the view is scrolled back from the end.
+	scroll int
+}
+
+var logs = &logPane{height: 8}
+
+// add records a message with its time.
+func (p *logPane) add(message string) {
+	line := fmt.Sprintf("%v %v", time.Now().Format("15:04:05"), message)
+	if len(p.messages) < logHistorySize {
+		p.messages = append(p.messages, line)
+	} else {
+		p.messages[p.next] = line
+		p.next = (p.next + 1) % logHistorySize
+	}
+	if p.scroll > 0 {
+		// keep the view on the same messages while scrolled back
+		p.scroll = min(p.scroll+1, p.maxScroll())
+	}
+}
+
+// lines returns the messages oldest first.
+func (p *logPane) lines() []string {
+	return append(p.messages[p.next:len(p.messages):len(p.messages)], p.messages[:p.next]...)
+}
+
+// maxScroll is how far back the view can scroll.
+func (p *logPane) maxScroll() int {
+	return max(len(p.messages)-p.height, 0)
+}
+
+// scrollBy scrolls back by n messages, or forward for negative n.
+func (p *logPane) scrollBy(n int) {
+	p.scroll = min(max(p.scroll+n, 0), p.maxScroll())
+}
+
+// toggle opens or collapses the pane, returning to the newest messages.
+func (p *logPane) toggle() {
+	p.open = !p.open
+	p.scroll = 0
+}
+
+// draw shows the pane above the status bar, or removes it when collapsed.
+func (p *logPane) draw(s tcell.Screen) {
+	if !p.open {
+		overlays.remove("logpane")
+		return
+	}
+	width, height := s.Size()
+	sf := overlays.surface("logpane", logPaneLayer)
+	sf.clear()
+
+	rows := min(p.height, max(height-logHeight, 0))
+	lines := p.lines()
+	end := len(lines) - p.scroll
+	start := max(end-rows, 0)
+	top := height - logHeight - rows
+	style := tcell.StyleDefault.Background(tcell.ColorBlack).Foreground(tcell.ColorWhite)
+	for y := 0; y < rows; y++ {
+		line := ""
+		if i := start + y - max(rows-(end-start), 0); i >= start && i < end {
+			line = lines[i]
+		}
+		sf.text(0, top+y, fmt.Sprintf("%-*s", width, line), style, 0.85)
+	}
+}
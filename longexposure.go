@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+
+	"gocv.io/x/gocv"
+)
+
+// longExposureMode selects how frames are accumulated for light painting.
+type longExposureMode int
+
+const (
+	longExposureOff longExposureMode = iota
+	// longExposureMax keeps the brightest value each pixel has had, so
+	// light sources leave trails without washing out the scene.
+	longExposureMax
+	// longExposureSum adds frames up, like an open camera shutter. It
+	// works best in the dark, since lit scenes saturate within frames.
+	longExposureSum
+)
+
+var longExposureNames = []string{"off", "max", "sum"}
+
+// longExposure is the active long-exposure mode.
+var longExposure longExposureMode
+
+// longExposureAccumulator holds the frames accumulated since the last
+// reset. It is only touched with imageFilters.mu held.
+var longExposureAccumulator *gocv.Mat
+
+func (m longExposureMode) String() string {
+	return longExposureNames[m]
+}
+
+// Set implements flag.Value.
+func (m *longExposureMode) Set(name string) error {
+	for i, n := range longExposureNames {
+		if n == name {
+			*m = longExposureMode(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown long exposure mode %q", name)
+}
+
+// next returns the mode following m, wrapping around.
+func (m longExposureMode) next() longExposureMode {
+	return (m + 1) % longExposureMode(len(longExposureNames))
+}
+
+// longExposureFilter accumulates every frame since the last reset into
+// one, so a flashlight waved in front of the camera draws persistent
+// trails.
+type longExposureFilter struct {
+	mode longExposureMode
+}
+
+func (longExposureFilter) name() string { return "long exposure" }
+
+func (f longExposureFilter) apply(img *gocv.Mat) {
+	acc := longExposureAccumulator
+	if acc == nil || acc.Rows() != img.Rows() || acc.Cols() != img.Cols() || acc.Type() != img.Type() {
+		closeLongExposure()
+		m := img.Clone()
+		longExposureAccumulator = &m
+		return
+	}
+	switch f.mode {
+	case longExposureMax:
+		gocv.Max(*acc, *img, acc)
+	case longExposureSum:
+		gocv.Add(*acc, *img, acc)
+	}
+	acc.CopyTo(img)
+}
+
+// closeLongExposure drops the accumulated frames.
+func closeLongExposure() {
+	if longExposureAccumulator != nil {
+		longExposureAccumulator.Close()
+		longExposureAccumulator = nil
+	}
+}
+
+// setLongExposure switches to mode m, starting a new exposure.
+func setLongExposure(m longExposureMode) {
+	longExposure = m
+	resetLongExposure()
+	if m == longExposureOff {
+		removeImageFilter(longExposureFilter{}.name())
+		return
+	}
+	setImageFilter(longExposureFilter{mode: m})
+}
+
+// resetLongExposure starts the exposure over from the next frame.
+func resetLongExposure() {
+	imageFilters.mu.Lock()
+	defer imageFilters.mu.Unlock()
+	closeLongExposure()
+}
+
+func longExposureReadout() string {
+	return fmt.Sprintf("Long Exposure: %v", longExposure)
+}
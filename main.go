@@ -1,31 +1,333 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"image"
+	"io"
 	"log"
 	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/gdamore/tcell"
-	"github.com/google/uuid"
+	"github.com/remzisenel/ascii-webcam/stream"
 	"gocv.io/x/gocv"
 )
 
 const (
-	logHeight = 1
+	// logHeight is the number of rows below the video, for the status bar
+	// and the log line.
+	logHeight = 2
 )
 
 var (
-	colorEnabled = false
-	runes        = []rune{' ', ' ', ' ', ' ', '.', ',', ':', ';', '+', '*', '?', '%', 'S', '#', '@'}
+	deviceID            = 0
+	colorEnabled        = false
+	pixelEnabled        = false
+	edgesEnabled        = false
+	glyphEnabled        = false
+	dither              = ditherNone
+	invertEnabled       = false
+	cvd                 = cvdNone
+	tint                = tintNone
+	serveAddr           = flag.String("serve", "", "serve the current frame and /metrics over HTTP on this address, e.g. :8080")
+	pprofAddr           = flag.String("pprof", "", "serve net/http/pprof profiles on this address, e.g. :6060")
+	safeColors          = flag.Bool("safe-palette", false, "use a color-blind safe palette for overlays and messages")
+	colors              = colorAuto
+	stereoDevice        = flag.Int("stereo", -1, "second camera device for stereo viewing (-1 to disable)")
+	stereoName          = flag.String("stereo-mode", "anaglyph", "how to combine stereo cameras: anaglyph or side-by-side")
+	matchRef            = flag.String("match-ref", "", "reference image whose color histogram every frame is matched to")
+	matchStereo         = flag.Bool("match-stereo", false, "match the stereo camera's color histogram to the main camera")
+	paletteFile         = flag.String("palettes", "", "file with extra false-color palettes, one \"name = #rrggbb #rrggbb ...\" per line")
+	configPath          = flag.String("config", "", "config file (default ~/.config/ascii-webcam/config.toml)")
+	rampName            = flag.String("ramp", "", "glyph ramp preset saved by the ramp subcommand")
+	charset             = flag.String("charset", "", "glyphs of the ramp from darkest to densest, e.g. \" .:-=+*#%@\" (overrides -ramp)")
+	warmupFrames        = flag.Int("warmup", 15, "frames to skip after opening the camera while exposure and white balance settle")
+	unfocusedFPS        = flag.Float64("unfocused-fps", 1, "refresh rate while the terminal is unfocused (0 pauses rendering)")
+	pauseStopsCapture   = flag.Bool("pause-stops-capture", false, "stop reading the camera while paused instead of discarding frames")
+	referenceCDF        *channelCDF
+	storage             storageConfig
+	captures            captureStorage = localStorage{dir: "."}
+	rotation                           = rotationAuto
+	deinterlacing       deinterlaceMode
+	stereoDeinterlacing deinterlaceMode
+	defaultRunes        = stream.DefaultRamp
+	runes               = append([]rune(nil), defaultRunes...)
 )
 
+// subcommand is run with the arguments after its name, the first argument.
+type subcommand struct {
+	run func(args []string) error
+	// summary is the one-line description listed by help.
+	summary string
+	// failure describes a failed run in the fatal error message.
+	failure string
+}
+
+// subcommands is filled in by init functions, so optional commands can be
+// left out of the minimal build.
+var subcommands = map[string]subcommand{}
+
+// defaultCommand runs when the first argument is a flag or missing.
+const defaultCommand = "view"
+
+func init() {
+	subcommands["view"] = subcommand{run: runView, summary: "show the camera in the terminal (default)", failure: "Error viewing"}
+	subcommands["help"] = subcommand{run: runHelp, summary: "list the commands, or show the flags of one", failure: "Error showing help"}
+}
+
 func main() {
-	webcam, err := gocv.VideoCaptureDevice(0)
+	name, args := defaultCommand, os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
+	cmd, ok := subcommands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Unknown command %q\n\n", name)
+		printCommands(os.Stderr)
+		os.Exit(2)
+	}
+	if err := cmd.run(args); err != nil {
+		log.Fatalf("%v: %v", cmd.failure, err)
+	}
+}
+
+// printCommands writes the program usage and the list of commands.
+func printCommands(w io.Writer) {
+	names := make([]string, 0, len(subcommands))
+	for name := range subcommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	program := filepath.Base(os.Args[0])
+	fmt.Fprintf(w, "Usage: %s [command] [flags]\n\nCommands:\n", program)
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-10s %s\n", name, subcommands[name].summary)
+	}
+	fmt.Fprintf(w, "\nRun \"%s <command> --help\" for the flags of a command.\n", program)
+}
+
+// runHelp implements the help subcommand. With a command name it shows the
+// flags of that command.
+func runHelp(args []string) error {
+	if len(args) == 0 {
+		printCommands(os.Stdout)
+		return nil
+	}
+	cmd, ok := subcommands[args[0]]
+	if !ok || args[0] == "help" {
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+	return cmd.run([]string{"--help"})
+}
+
+// runView implements the view subcommand, the interactive terminal viewer.
+func runView(args []string) error {
+	flag.IntVar(&deviceID, "device", 0, "camera device to capture from")
+	flag.Var(&dither, "dither", "dithering: none, bayer or fs")
+	flag.BoolVar(&invertEnabled, "invert", false, "invert the glyph ramp for light terminal themes")
+	flag.BoolVar(&autoExposure, "auto-exposure", false, "adjust brightness and contrast continuously from the image histogram")
+	flag.BoolVar(&thresholdEnabled, "threshold", false, "render only the darkest and densest glyphs, split at an adjustable level")
+	flag.Var(&colors, "colors", "color mode: auto, truecolor, 256 or 16")
+	flag.StringVar(&falseColor, "false-color", "", "map luminance through a palette: thermal, viridis, magma or one from -palettes")
+	flag.Var(&tint, "tint", "monochrome theme: none, amber, green or cyan")
+	flag.Var(&cvd, "simulate-cvd", "simulate color vision deficiency: none, protanopia, deuteranopia or tritanopia")
+	flag.Var(&deinterlacing, "deinterlace", "deinterlace the camera: none, blend or bob")
+	flag.Var(&stereoDeinterlacing, "stereo-deinterlace", "deinterlace the stereo camera: none, blend or bob")
+	flag.IntVar(&onionSkinFrames, "onion-skin", 0, "blend up to 8 previous frames into each frame as fading trails (0 disables)")
+	flag.Var(&longExposure, "long-exposure", "accumulate frames for light painting: off, max or sum")
+	flag.IntVar(&stackFrames, "stack", 0, "average this many frames to remove noise from static scenes (0 disables)")
+	flag.Var(&flicker, "flicker", "reduce lamp flicker banding for the mains frequency: off, 50 or 60")
+	flag.IntVar(&supersample, "supersample", 1, "average NxN source pixels per cell to reduce shimmering, up to 4 (1 disables)")
+	flag.IntVar(&logs.height, "log-pane-height", 8, "rows of the log pane toggled with the backtick key")
+	flag.BoolVar(&showFPS, "show-fps", false, "show the capture and render frame rates")
+	flag.BoolVar(&showResources, "show-resources", false, "show the CPU, memory and goroutine usage of the process")
+	flag.Float64Var(&stillThreshold, "still-threshold", stillThreshold, "mean brightness change out of 255 below which frames of a static scene are not redrawn (0 redraws every frame)")
+	flag.BoolVar(&adaptiveQuality, "adaptive-quality", adaptiveQuality, "lower the capture resolution while frames take too long to convert and draw")
+	flag.BoolVar(&useGPU, "gpu", false, "resize frames on a CUDA GPU, converting them to gray there while no image filter is on (image filters always run on the CPU)")
+	flag.BoolVar(&rememberSettings, "remember-settings", rememberSettings, "restore the zoom, filters and charset last used with a camera or file when it is opened again")
+	flag.BoolVar(&interlaced, "interlaced", false, "convert even and odd rows on alternate frames to halve the work on large terminals")
+	interp := interpolationLinear
+	flag.Var(&interp, "interpolation", "resize interpolation: nearest, linear, area, cubic or lanczos")
+	fit := fitStretch
+	flag.Var(&fit, "fit", "how frames fit the terminal: stretch, fill, fit or letterbox")
+	flag.Var(&letterboxColor, "letterbox-color", "color of the letterbox bars as #rrggbb")
+	mirror := flag.Bool("mirror", false, "flip frames horizontally, like a mirror")
+	flip := flag.Bool("flip", false, "flip frames vertically")
+	flag.Var(&rotation, "rotate", "clockwise frame rotation: auto, 0, 90, 180 or 270")
+	flag.StringVar(&storage.kind, "storage", "local", "where captures are stored: local, s3 or webdav")
+	flag.StringVar(&storage.dir, "storage-dir", ".", "directory for local storage")
+	flag.StringVar(&screenshotDir, "screenshot-dir", "", "directory for screenshots, inside the storage directory or bucket")
+	flag.Var(&screenshotAs, "screenshot-format", fmt.Sprintf("screenshot file format: %v (text is saved as .ans when colored)", strings.Join(screenshotFormatNames(), ", ")))
+	flag.StringVar(&screenshotName, "screenshot-name", screenshotName, screenshotNameHelp)
+	flag.StringVar(&storage.url, "storage-url", "", "WebDAV collection URL or S3 endpoint URL")
+	flag.StringVar(&storage.bucket, "s3-bucket", "", "S3 bucket for captures")
+	flag.StringVar(&storage.region, "s3-region", "us-east-1", "S3 region for request signing")
+	bench := flag.Duration("bench", 0, "run the pipeline headlessly for this long, e.g. 10s, and print frame rate, stage latency and allocation stats")
+	benchInput := flag.String("bench-input", "", "video file to benchmark with (default: synthetic noise)")
+	benchSize := flag.String("bench-size", "160x48", "terminal size to benchmark, as COLSxROWS")
+	logFile := flag.String("log-file", "", "file to append diagnostics such as camera errors and dropped frames to")
+	logLevel := flag.String("log-level", "info", "lowest level written to -log-file: debug, info, warn or error")
+	flag.CommandLine.Usage = func() {
+		printCommands(flag.CommandLine.Output())
+		fmt.Fprintf(flag.CommandLine.Output(), "\nFlags of %s:\n", defaultCommand)
+		flag.PrintDefaults()
+	}
+	flag.CommandLine.Parse(args)
+	if flag.NArg() > 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	// only flags from the command line override remembered settings
+	flag.Visit(func(f *flag.Flag) { givenFlags[f.Name] = true })
+
+	// settings in the config file are defaults for flags not given
+	cfg, err := loadConfig(*configPath)
+	if err != nil {
+		log.Fatalf("Error loading config: %v", err)
+	}
+	if err := applyFlagDefaults(cfg, flag.CommandLine, false); err != nil {
+		log.Fatalf("Error loading config: %v", err)
+	}
+
+	if *logFile != "" {
+		f, err := openLogFile(*logFile, *logLevel)
+		if err != nil {
+			log.Fatalf("Error opening log file: %v", err)
+		}
+		defer f.Close()
+	}
+	diag.Info("starting", "device", deviceID, "args", os.Args[1:])
+
+	storage.user = os.Getenv("WEBDAV_USER")
+	storage.password = os.Getenv("WEBDAV_PASSWORD")
+	backend, err := newStorage(storage)
 	if err != nil {
-		log.Fatalf("Error opening capture device: %v", err)
+		log.Fatalf("Error configuring storage: %v", err)
+	}
+	captures = backend
+
+	if *safeColors {
+		palette = safePalette
+	}
+
+	if *rampName != "" {
+		ramp, err := loadRamp(*rampName)
+		if err != nil {
+			log.Fatalf("Error loading ramp: %v", err)
+		}
+		runes = ramp
+	}
+	if *charset != "" {
+		if len([]rune(*charset)) < 2 {
+			log.Fatalf("Error parsing flags: -charset needs at least two glyphs")
+		}
+		runes = []rune(*charset)
+	}
+
+	if err := bindKeys(cfg); err != nil {
+		log.Fatalf("Error loading config: %v", err)
+	}
+	if err := applyConfig(cfg); err != nil {
+		log.Fatalf("Error loading config: %v", err)
+	}
+
+	if *paletteFile != "" {
+		if err := loadPalettes(*paletteFile); err != nil {
+			log.Fatalf("Error loading palettes: %v", err)
+		}
+	}
+	if err := configurePresets(cfg); err != nil {
+		log.Fatalf("Error loading config: %v", err)
+	}
+	if _, ok := activePalette(); falseColor != "" && !ok {
+		log.Fatalf("Error parsing flags: unknown false-color palette %q", falseColor)
+	}
+
+	if *serveAddr != "" {
+		if err := startServer(*serveAddr); err != nil {
+			log.Fatalf("Error starting server: %v", err)
+		}
+	}
+
+	if *pprofAddr != "" {
+		if err := startProfiler(*pprofAddr); err != nil {
+			log.Fatalf("Error starting profiler: %v", err)
+		}
+	}
+
+	if rotation != rotationAuto {
+		frameRotation.Store(int32(rotation))
+	}
+
+	startMessage := ""
+	if useGPU && !gpuAvailable() {
+		useGPU = false
+		diag.Warn("no CUDA device, resizing on the CPU")
+		if startMessage == "" {
+			startMessage = "No CUDA device found, resizing on the CPU"
+		}
+	}
+
+	setFlicker(flicker)
+	setOnionSkin(onionSkinFrames)
+	setLongExposure(longExposure)
+	setStack(stackFrames)
+
+	frameFit.Store(int32(fit))
+	interpolation.Store(int32(interp))
+	updateSamples()
+	mirrorFrame.Store(*mirror)
+	flipFrame.Store(*flip)
+
+	if *matchRef != "" {
+		cdf, err := loadReferenceCDF(*matchRef)
+		if err != nil {
+			log.Fatalf("Error loading histogram reference: %v", err)
+		}
+		referenceCDF = &cdf
+	}
+
+	if *bench > 0 {
+		cols, rows, err := parseBenchSize(*benchSize)
+		if err != nil {
+			log.Fatalf("Error parsing flags: %v", err)
+		}
+		if err := runBench(os.Stdout, *benchInput, cols, rows, *bench); err != nil {
+			log.Fatalf("Benchmark failed: %v", err)
+		}
+		return nil
+	}
+
+	// the capture goroutine keeps retrying a camera that is not there yet
+	webcam, err := openCamera()
+	if err != nil {
+		diag.Warn("camera unavailable", "err", err)
+		startMessage = fmt.Sprintf("Camera %d unavailable, retrying: %v", deviceID, err)
+	}
+
+	var stereo *gocv.VideoCapture
+	mode, err := parseStereoMode(*stereoName)
+	if err != nil {
+		log.Fatalf("Error parsing flags: %v", err)
+	}
+	if *stereoDevice >= 0 {
+		stereo, err = gocv.VideoCaptureDevice(*stereoDevice)
+		if err != nil {
+			log.Fatalf("Error opening stereo capture device: %v", err)
+		}
+		defer stereo.Close()
+
+		// anaglyphs only work in color
+		colorEnabled = colorEnabled || mode == stereoAnaglyph
 	}
-	defer webcam.Close()
 
 	// Create screen
 	s, err := tcell.NewScreen()
@@ -43,165 +345,998 @@ func main() {
 
 	s.Clear()
 
+	if colors == colorAuto {
+		var reason string
+		colors, reason = detectColorDepth(s)
+		if startMessage == "" {
+			startMessage = fmt.Sprintf("Color mode: %v (%v)", colors, reason)
+		}
+	}
+
+	pushTitle()
+	enableFocusReporting()
+	stopSignals := handleSignals(s)
+
+	func() {
+		defer restoreTerminal(s)
+		runViewer(s, defStyle, startMessage, firstRun(), simulateLink(func(imageChan chan<- capturedImage, done <-chan struct{}) {
+			webcamReader(webcam, stereo, mode, imageChan, done)
+		}))
+	}()
+
+	stopSignals()
+	resetTerminal(s)
+	return nil
+}
+
+// captureFunc produces images for the viewer until done is closed.
+type captureFunc func(imageChan chan<- capturedImage, done <-chan struct{})
+
+// runViewer runs the interactive viewer on s until the user quits, drawing
+// images produced by capture. startMessage, if set, is shown in the log line
+// and showTutorial starts the tutorial overlay.
+func runViewer(s tcell.Screen, defStyle tcell.Style, startMessage string, showTutorial bool, capture captureFunc) {
+	setTitle(statusTitle(deviceID, 0, false))
+
+	overlays = &compositor{defStyle: defStyle}
+	s.EnableMouse()
+	quality.reset()
+	setCaptureSize(quality.size(videoSize(s)))
+	if startMessage != "" {
+		logMessage(s, startMessage)
+	}
+
 	eventChan := make(chan event)
-	imageChan := make(chan image.Image)
+	commandChan := make(chan commandInput)
+	dragChan := make(chan cropDrag)
+	captured := make(chan capturedImage)
+	imageChan := make(chan capturedImage)
+	var holdCapture atomic.Bool
+	done := make(chan struct{})
+	captureDone := make(chan struct{})
+
+	exports := newExportPool(0)
+	sources := newSourceMemory()
+	hooks.fire("start", nil)
+
+	var tut tutorial
+	if showTutorial {
+		tut.start()
+		tut.draw(s)
+	}
 
-	go eventListener(s, eventChan)
-	go webcamReader(webcam, s, imageChan)
+	go func() {
+		defer restoreTerminal(s)
+		eventListener(s, eventChan, commandChan, dragChan)
+	}()
+	go func() {
+		defer restoreTerminal(s)
+		capture(captured, done)
+		close(captureDone)
+	}()
+	go keepLatest(captured, imageChan, &holdCapture, done)
 
-	var lastImage *image.Image
+	fpsTicker := time.NewTicker(time.Second)
+	defer fpsTicker.Stop()
+	frames := 0
+	// titleFPS is the frame rate last shown in the title
+	titleFPS := 0
+
+	var lastFrame *frame
+	var history frameRing
+	var fps fpsCounter
+	var resources resourceSampler
+	menu := newSettingsMenu()
+	var lastDraw time.Time
+	var motion motionDetector
+	var scenes sceneTracker
+	var tiles tiledConverter
+	var still stillDetector
+	var resizeSettled <-chan time.Time
+	unfocused := false
+	paused := false
+	helpOpen := false
 	for {
+		images := imageChan
+		grayFrames.Store(!colorEnabled && !pixelEnabled)
+		holdCapture.Store(paused && *pauseStopsCapture)
+		if holdCapture.Load() {
+			images = nil
+		}
+
 		select {
 		case ev := <-eventChan:
+			// any key may change how the scene is drawn
+			still.reset()
 			switch ev {
 			case resize:
-				logMessage(s, "Resize Requested")
-				s.Sync()
+				// show the last frame stretched right away, and resize the
+				// capture once the size stops changing
+				width, height := videoSize(s)
+				overlays.setVideo(videoFrame(lastFrame, width, height))
+				// the terminal may have lost what was drawn
+				overlays.invalidate()
+				if helpOpen {
+					drawHelp(s)
+				}
+				resizeSettled = time.After(resizeDebounce)
 			case screenshot:
-				filename, err := dumpImageToFile(*lastImage)
-				if err != nil {
-					logMessage(s, fmt.Sprintf("Error dumping image to file: %v", err))
+				shown := showFrame(lastFrame)
+				job := exportJob{name: "Screenshot", run: func() (string, error) { return saveScreenshot(shown) }}
+				if exports.submit(job) {
+					logMessage(s, fmt.Sprintf("Exporting screenshot (%d in progress)", exports.queued()))
+				} else {
+					diag.Warn("export queue full, dropping screenshot")
+					logMessage(s, "Export queue full, screenshot dropped")
+				}
+			case recordToggle:
+				if isRecording() {
+					if exports.submit(stopRecording()) {
+						logMessage(s, "Recording stopped, saving")
+					} else {
+						diag.Warn("export queue full, dropping recording")
+						logMessage(s, "Export queue full, recording dropped")
+					}
+				} else if err := startRecording(); err != nil {
+					logMessage(s, fmt.Sprintf("Cannot record: %v", err))
 				} else {
-					logMessage(s, fmt.Sprintf("Screenshot saved to file: %v", filename))
+					logMessage(s, "Recording")
 				}
+				setTitle(statusTitle(deviceID, titleFPS, isRecording()))
 			case colorToggle:
 				logMessage(s, "Color Toggle")
 				colorEnabled = !colorEnabled
-			case increaseBrightness:
-				logMessage(s, "Increase Brightness")
-				if runes[0] == ' ' {
-					runes = runes[1:]
+			case pixelToggle:
+				logMessage(s, "Pixel Mode Toggle")
+				pixelEnabled = !pixelEnabled
+			case edgesToggle:
+				logMessage(s, "Edge Mode Toggle")
+				edgesEnabled = !edgesEnabled
+			case glyphToggle:
+				logMessage(s, "Glyph Matching Toggle")
+				glyphEnabled = !glyphEnabled
+				updateSamples()
+			case invertToggle:
+				logMessage(s, "Invert Ramp Toggle")
+				invertEnabled = !invertEnabled
+			case cvdCycle:
+				cvd = cvd.next()
+				logMessage(s, fmt.Sprintf("Color Vision Simulation: %v", cvd))
+			case falseColorCycle:
+				falseColor = nextPalette()
+				logMessage(s, falseColorReadout())
+			case rainToggle:
+				if overlays.rain == nil {
+					overlays.rain = newMatrixRain()
+					logMessage(s, "Matrix Rain: on")
+				} else {
+					overlays.rain = nil
+					logMessage(s, "Matrix Rain: off")
+				}
+			case tintCycle:
+				tint = tint.next()
+				logMessage(s, fmt.Sprintf("Tint: %v", tint))
+			case ditherCycle:
+				dither = dither.next()
+				logMessage(s, fmt.Sprintf("Dithering: %v", dither))
+			case charsetCycle:
+				nextRamp(1)
+				logMessage(s, rampReadout())
+			case keystoneSelect:
+				if corner := perspective.selectNext(); corner != "" {
+					logMessage(s, fmt.Sprintf("Keystone: adjusting %v corner with arrow keys", corner))
+				} else {
+					logMessage(s, "Keystone: done")
 				}
+			case keystoneReset:
+				logMessage(s, "Keystone Reset")
+				perspective.reset()
+			case keystoneLeft:
+				perspective.nudge(-1, 0)
+			case keystoneRight:
+				perspective.nudge(1, 0)
+			case keystoneUp:
+				perspective.nudge(0, -1)
+			case keystoneDown:
+				perspective.nudge(0, 1)
+			case cropClear:
+				frameCrop.clear()
+				logMessage(s, "Crop: cleared")
+			case zoomIn, zoomOut:
+				steps := 1
+				if ev == zoomOut {
+					steps = -1
+				}
+				logMessage(s, fmt.Sprintf("Zoom: %.1fx", view.changeZoom(steps)))
+			case panLeft:
+				view.pan(-1, 0)
+			case panRight:
+				view.pan(1, 0)
+			case panUp:
+				view.pan(0, -1)
+			case panDown:
+				view.pan(0, 1)
+			case exposureToggle:
+				autoExposure = !autoExposure
+				logMessage(s, exposureReadout())
+			case increaseBrightness:
+				changeBrightness(1)
+				logMessage(s, levelsReadout())
 			case decreaseBrightness:
-				logMessage(s, "Decrease Brightness")
-				runes = append([]rune{' '}, runes...)
+				changeBrightness(-1)
+				logMessage(s, levelsReadout())
+			case increaseContrast:
+				changeContrast(1)
+				logMessage(s, levelsReadout())
+			case decreaseContrast:
+				changeContrast(-1)
+				logMessage(s, levelsReadout())
+			case increaseSaturation:
+				changeSaturation(1)
+				logMessage(s, saturationReadout())
+			case decreaseSaturation:
+				changeSaturation(-1)
+				logMessage(s, saturationReadout())
+			case hueLeft:
+				changeHue(-1)
+				logMessage(s, hueReadout())
+			case hueRight:
+				changeHue(1)
+				logMessage(s, hueReadout())
+			case hueCycleToggle:
+				toggleHueCycle()
+				logMessage(s, hueReadout())
+			case warmer:
+				changeTemperature(1)
+				logMessage(s, temperatureReadout())
+			case cooler:
+				changeTemperature(-1)
+				logMessage(s, temperatureReadout())
+			case negativeToggle:
+				if toggleFilter(negativeFilter{}) {
+					logMessage(s, "Negative: on")
+				} else {
+					logMessage(s, "Negative: off")
+				}
+			case increaseSharpen:
+				changeSharpen(1)
+				logMessage(s, sharpenReadout())
+			case decreaseSharpen:
+				changeSharpen(-1)
+				logMessage(s, sharpenReadout())
+			case increaseDenoise:
+				changeDenoise(1)
+				logMessage(s, denoiseReadout())
+			case decreaseDenoise:
+				changeDenoise(-1)
+				logMessage(s, denoiseReadout())
+			case increaseOnionSkin:
+				changeOnionSkin(1)
+				logMessage(s, onionSkinReadout())
+			case decreaseOnionSkin:
+				changeOnionSkin(-1)
+				logMessage(s, onionSkinReadout())
+			case longExposureCycle:
+				setLongExposure(longExposure.next())
+				logMessage(s, longExposureReadout())
+			case longExposureReset:
+				if longExposure == longExposureOff {
+					logMessage(s, "Long Exposure is off")
+				} else {
+					resetLongExposure()
+					logMessage(s, "Long Exposure: restarted")
+				}
+			case stackCycle:
+				nextStack()
+				logMessage(s, stackReadout())
+			case flickerCycle:
+				setFlicker(flicker.next())
+				logMessage(s, flickerReadout())
+			case whiteBalanceToggle:
+				if toggleImageFilter(whiteBalanceFilter{}) {
+					logMessage(s, "Auto White Balance: on")
+				} else {
+					logMessage(s, "Auto White Balance: off")
+				}
+			case claheToggle:
+				if toggleImageFilter(claheFilter{}) {
+					logMessage(s, "Adaptive Equalization: on")
+				} else {
+					logMessage(s, "Adaptive Equalization: off")
+				}
+			case cartoonToggle:
+				if toggleImageFilter(cartoonFilter{}) {
+					logMessage(s, "Cartoon: on")
+				} else {
+					logMessage(s, "Cartoon: off")
+				}
+			case nightVisionToggle:
+				if togglePreset(nightVisionPreset) {
+					logMessage(s, fmt.Sprintf("%v: on", nightVisionPreset.name))
+				} else {
+					logMessage(s, fmt.Sprintf("%v: off", nightVisionPreset.name))
+				}
+			case rotateCycle:
+				degrees := (frameRotation.Load() + 90) % 360
+				frameRotation.Store(degrees)
+				logMessage(s, fmt.Sprintf("Rotation: %d deg", degrees))
+			case interlacedToggle:
+				interlaced = !interlaced
+				if interlaced {
+					logMessage(s, "Interlaced Rendering: on")
+				} else {
+					logMessage(s, "Interlaced Rendering: off")
+				}
+			case interpolationCycle:
+				mode := interpolationMode(interpolation.Load()).next()
+				interpolation.Store(int32(mode))
+				logMessage(s, fmt.Sprintf("Interpolation: %v", mode))
+			case fitCycle:
+				mode := fitMode(frameFit.Load()).next()
+				frameFit.Store(int32(mode))
+				logMessage(s, fmt.Sprintf("Fit: %v", mode))
+			case mirrorToggle:
+				if !mirrorFrame.Load() {
+					mirrorFrame.Store(true)
+					logMessage(s, "Mirror: on")
+				} else {
+					mirrorFrame.Store(false)
+					logMessage(s, "Mirror: off")
+				}
+			case flipToggle:
+				if !flipFrame.Load() {
+					flipFrame.Store(true)
+					logMessage(s, "Vertical Flip: on")
+				} else {
+					flipFrame.Store(false)
+					logMessage(s, "Vertical Flip: off")
+				}
+			case thresholdToggle:
+				thresholdEnabled = !thresholdEnabled
+				logMessage(s, thresholdReadout())
+			case increaseThreshold:
+				changeThreshold(1)
+				logMessage(s, thresholdReadout())
+			case decreaseThreshold:
+				changeThreshold(-1)
+				logMessage(s, thresholdReadout())
+			case increasePosterize:
+				changePosterize(1)
+				logMessage(s, posterizeReadout())
+			case decreasePosterize:
+				changePosterize(-1)
+				logMessage(s, posterizeReadout())
+			case chordPending:
+				logMessage(s, "Space-")
+			case chordUnbound:
+				logMessage(s, "Chord cancelled")
+			case resetAdjustments:
+				resetAdjustmentSettings()
+				logMessage(s, "Image adjustments reset")
+			case tutorialStart:
+				tut.start()
+				logMessage(s, "")
+			case pauseToggle:
+				paused = !paused
+				if paused {
+					logMessage(s, "Paused")
+				} else {
+					logMessage(s, "Resumed")
+				}
+			case menuToggle:
+				if menuOpen.Load() {
+					menuOpen.Store(false)
+					closeMenu()
+				} else {
+					menuOpen.Store(true)
+					menu.draw()
+				}
+			case menuUp, menuDown:
+				dir := 1
+				if ev == menuUp {
+					dir = -1
+				}
+				menu.move(dir)
+				menu.draw()
+			case menuLeft, menuRight:
+				dir := 1
+				if ev == menuLeft {
+					dir = -1
+				}
+				logMessage(s, menu.adjust(dir))
+				menu.draw()
+			case logPaneToggle:
+				logs.toggle()
+				logs.draw(s)
+			case logScrollUp, logScrollDown:
+				if !logs.open {
+					break
+				}
+				n := logs.height - 1
+				if ev == logScrollDown {
+					n = -n
+				}
+				logs.scrollBy(n)
+				logs.draw(s)
+			case fpsToggle:
+				showFPS = !showFPS
+				drawFPS(s, 0, 0)
+				if showFPS {
+					logMessage(s, "FPS Counter: on")
+				} else {
+					logMessage(s, "FPS Counter: off")
+				}
+			case preset1, preset2, preset3, preset4, preset5, preset6, preset7, preset8, preset9:
+				logMessage(s, applyPreset(int(ev-preset1)+1))
+			case helpToggle:
+				helpOpen = !helpOpen
+				if helpOpen {
+					drawHelp(s)
+				} else {
+					closeHelp()
+				}
+			case resourcesToggle:
+				showResources = !showResources
+				cpu, rss, goroutines := resources.sample()
+				drawResources(s, cpu, rss, goroutines)
+				if showResources {
+					logMessage(s, "Resource Usage: on")
+				} else {
+					logMessage(s, "Resource Usage: off")
+				}
+			case stepForward, stepBackward:
+				if !paused {
+					logMessage(s, fmt.Sprintf("Frame stepping works while paused, press %v to pause", keyFor(pauseToggle)))
+					break
+				}
+				n := 1
+				if ev == stepBackward {
+					n = -1
+				}
+				if f := history.step(n); f != nil {
+					lastFrame = f
+					latestFrame.Store(f)
+					width, height := videoSize(s)
+					overlays.setVideo(videoFrame(f, width, height))
+					logMessage(s, fmt.Sprintf("Frame %d/%d", history.pos+1, len(history.frames)))
+				}
+			case focusIn:
+				unfocused = false
+			case focusOut:
+				unfocused = true
 			case quit:
-				s.Fini()
-				os.Exit(0)
-			}
-		case img := <-imageChan:
-			width, height := img.Bounds().Dx(), img.Bounds().Dy()
-			for y := 0; y < height; y++ {
-				for x := 0; x < width; x++ {
-					pixelColor := img.At(x, y)
-					r, g, b, _ := pixelColor.RGBA()
-					brightness := float32(r)/0xffff*0.299 + float32(g)/0xffff*0.587 + float32(b)/0xffff*0.114
-					runeIndex := int(float32(len(runes)-1) * brightness)
-
-					if colorEnabled {
-						color := tcell.NewRGBColor(int32(r), int32(g), int32(b))
-						s.SetContent(x, y, runes[runeIndex], nil, defStyle.Foreground(color))
-					} else {
-						s.SetContent(x, y, runes[runeIndex], nil, defStyle)
+				diag.Info("quitting")
+				if rememberSettings {
+					sources.remember()
+				}
+				if isRecording() {
+					if _, err := stopRecording().run(); err != nil {
+						diag.Error("export failed", "export", "Recording", "err", err)
 					}
 				}
+				hooks.fire("quit", nil)
+				close(done)
+				<-captureDone
+				return
 			}
-			s.Sync()
-			lastImage = &img
-		}
-	}
-}
-
-func dumpImageToFile(img image.Image) (string, error) {
-	if img == nil {
-		return "", fmt.Errorf("image is nil")
-	}
+			tut.handle(ev)
+			tut.draw(s)
+			overlays.draw(s)
+			s.Show()
+		case r := <-exports.results:
+			if r.err != nil {
+				diag.Error("export failed", "export", r.name, "err", r.err)
+				logMessage(s, fmt.Sprintf("Error exporting %v: %v", strings.ToLower(r.name), r.err))
+			} else {
+				logMessage(s, fmt.Sprintf("%v saved to file: %v (%d exports pending)", r.name, r.output, r.pending))
+				notify("ascii-webcam", fmt.Sprintf("%v saved to %v", r.name, r.output))
+				hooks.fire(strings.ToLower(r.name), map[string]string{"file": r.output})
+			}
+		case err := <-hooks.failures:
+			diag.Warn("hook failed", "err", err)
+			logMessage(s, err.Error())
+		case in := <-commandChan:
+			still.reset()
+			switch {
+			case in.cancelled:
+				logMessage(s, "")
+			case in.done:
+				runCommand(s, in.text, &tut)
+			default:
+				logMessage(s, ":"+in.text)
+			}
+		case d := <-dragChan:
+			still.reset()
+			_, height := s.Size()
+			if d.rect.Min.Y >= height-logHeight {
+				// clicks on the status bar toggle the setting under them
+				if d.done && d.rect.Dx() == 0 && d.rect.Dy() == 0 && d.rect.Min.Y == height-logHeight {
+					if message, ok := clickStatusBar(d.rect.Min.X); ok {
+						logMessage(s, message)
+					}
+				}
+			} else if !d.done {
+				drawCropDrag(d.rect)
+			} else if finishCropDrag(d.rect, overlays.videoBounds(s.Size())) {
+				logMessage(s, fmt.Sprintf("Crop: %dx%d cells selected, press %v to clear", d.rect.Dx()+1, d.rect.Dy()+1, keyFor(cropClear)))
+			}
+			overlays.draw(s)
+			s.Show()
+		case img := <-images:
+			if img.problem != "" {
+				logMessage(s, img.problem)
+				continue
+			}
+			if img.img == nil {
+				drawWarmup(s, img.warmup)
+				overlays.draw(s)
+				s.Show()
+				continue
+			}
+			clearWarmup()
 
-	uuid := uuid.New()
-	filename := fmt.Sprintf("screenshot-%v.txt", uuid)
+			if paused {
+				diag.Debug("dropping frame while paused")
+				metrics.dropped.Add(1)
+				releaseImage(img.img)
+				continue
+			}
 
-	file, err := os.Create(filename)
-	if err != nil {
-		log.Fatalf("Error creating file: %v", err)
-	}
+			// save power while the terminal is in the background
+			if unfocused && (*unfocusedFPS <= 0 || time.Since(lastDraw) < time.Duration(float64(time.Second) / *unfocusedFPS)) {
+				diag.Debug("dropping frame while unfocused")
+				metrics.dropped.Add(1)
+				releaseImage(img.img)
+				continue
+			}
+			if !still.changed(img.img) {
+				releaseImage(img.img)
+				continue
+			}
+			lastDraw = time.Now()
 
-	width, height := img.Bounds().Dx(), img.Bounds().Dy()
-	for y := 0; y < height; y++ {
-		for x := 0; x < width; x++ {
-			pixelColor := img.At(x, y)
-			r, g, b, _ := pixelColor.RGBA()
-			brightness := float32(r)/0xffff*0.299 + float32(g)/0xffff*0.587 + float32(b)/0xffff*0.114
-			runeIndex := int(float32(len(runes)-1) * brightness)
+			converting := time.Now()
+			f := tiles.convert(img.img, img.cols, img.rows)
+			metrics.conversion.observe(time.Since(converting))
+			releaseImage(img.img)
+			f.meta = img.meta
+			if f.meta.source != statusSource {
+				statusSource = f.meta.source
+				if rememberSettings {
+					sources.switchTo(statusSource)
+				}
+				drawStatusBar(s)
+			}
+			updateExposure(f)
+			change, changed := frameChange(lastFrame, f)
+			f.meta.scene = scenes.feed(change)
+			if change >= motionThreshold {
+				f.meta.detections = append(f.meta.detections, detection{label: "motion", box: changed, score: change})
+			}
+			width, height := videoSize(s)
+			overlays.setVideo(videoFrame(f, width, height))
+			overlays.draw(s)
+			s.Show()
+			if adaptiveQuality && quality.feed(time.Since(lastDraw)) {
+				setCaptureSize(quality.size(width, height))
+				logMessage(s, qualityReadout())
+			}
+			metrics.rendered.Add(1)
+			lastFrame = f
+			latestFrame.Store(f)
+			history.push(f)
+			recordFrame(f)
+			frames++
 
-			file.Write([]byte(string(runes[runeIndex])))
+			if motion.feed(change) {
+				hooks.fire("motion", map[string]string{
+					"motion": fmt.Sprintf("%.2f", change),
+					"source": f.meta.source,
+					"scene":  strconv.Itoa(f.meta.scene),
+				})
+			}
+		case <-resizeSettled:
+			resizeSettled = nil
+			width, height := videoSize(s)
+			setCaptureSize(quality.size(width, height))
+			logMessage(s, fmt.Sprintf("Resized to %dx%d", width, height))
+			// repaint everything once, in case the terminal garbled the
+			// screen while resizing; frames only send the changed cells
+			s.Sync()
+		case <-fpsTicker.C:
+			titleFPS = frames
+			setTitle(statusTitle(deviceID, titleFPS, isRecording()))
+			if captureRate := fps.sample(); showFPS {
+				drawFPS(s, captureRate, frames)
+				overlays.draw(s)
+				s.Show()
+			}
+			if cpu, rss, goroutines := resources.sample(); showResources {
+				drawResources(s, cpu, rss, goroutines)
+				overlays.draw(s)
+				s.Show()
+			}
+			frames = 0
 		}
-		file.Write([]byte("\n"))
 	}
-
-	if err := file.Close(); err != nil {
-		log.Fatalf("Error closing file: %v", err)
-	}
-
-	return filename, nil
 }
 
-func logMessage(s tcell.Screen, message string) {
-	s.Clear()
+var (
+	foregroundStyles = newStyleCache(false)
+	backgroundStyles = newStyleCache(true)
+)
 
+// logMessage shows a message on the log line overlay, below the status bar.
+func logMessage(s tcell.Screen, message string) {
 	width, height := s.Size()
+	bar := overlays.surface("log", logLayer)
+	bar.clear()
 
-	baseY := height - logHeight
+	baseY := height - 1
 
-	for i := 0; i < len(message); i++ {
+	for i, r := range []rune(message) {
 		yOffset := i / width
 		y := baseY + yOffset
 
 		x := i % width
-		s.SetContent(x, y, rune(message[i]), nil, tcell.StyleDefault.Foreground(tcell.ColorRed))
+		bar.set(x, y, overlayCell{r: r, style: tcell.StyleDefault.Foreground(palette.message), alpha: 1})
+	}
+	if message != "" && !strings.HasPrefix(message, ":") {
+		logs.add(message)
+		diag.Debug("message", "text", message)
 	}
-	s.Sync()
+	logs.draw(s)
+	drawStatusBar(s)
+	overlays.draw(s)
+	s.Show()
+}
+
+// capturedImage is a resized webcam image covering a grid of cols x rows
+// cells, with the metadata its frame starts out with. While the camera is
+// warming up img is nil and warmup reports the progress in [0, 1].
+type capturedImage struct {
+	img        image.Image
+	cols, rows int
+	meta       frameMeta
+	warmup     float32
+	// problem, when set, describes a capture error the capture is
+	// recovering from.
+	problem string
+}
+
+// latestFrame is the most recently converted frame, shared with the HTTP
+// handlers. Frames are never modified after conversion.
+var latestFrame atomic.Pointer[frame]
+
+// samplesX and samplesY hold cellSamples() for the capture goroutine.
+var samplesX, samplesY atomic.Int32
+
+func init() {
+	samplesX.Store(1)
+	samplesY.Store(1)
+}
+
+// updateSamples makes the capture provide cellSamples() pixels per cell.
+func updateSamples() {
+	sx, sy := cellSamples()
+	samplesX.Store(int32(sx))
+	samplesY.Store(int32(sy))
 }
 
-func webcamReader(webcam *gocv.VideoCapture, s tcell.Screen, imageChan chan<- image.Image) {
+// webcamReader captures, resizes and forwards images from the webcam. When
+// stereo is not nil its frames are combined with the webcam's using mode.
+// The reader owns webcam: it reopens the device after repeated read
+// failures, or opens it in the first place when webcam is nil, reporting
+// errors to the viewer instead of giving up.
+func webcamReader(webcam, stereo *gocv.VideoCapture, mode stereoMode, imageChan chan<- capturedImage, done <-chan struct{}) {
+	defer func() {
+		if webcam != nil {
+			webcam.Close()
+		}
+	}()
+	defer closeGPU()
+
 	img := gocv.NewMat()
 	defer img.Close()
 
 	small := gocv.NewMat()
 	defer small.Close()
 
+	left := gocv.NewMat()
+	defer left.Close()
+
+	right := gocv.NewMat()
+	defer right.Close()
+
+	warped := gocv.NewMat()
+	defer warped.Close()
+
+	rotated := gocv.NewMat()
+	defer rotated.Close()
+
+	flipped := gocv.NewMat()
+	defer flipped.Close()
+
+	padded := gocv.NewMat()
+	defer padded.Close()
+
+	scratch := gocv.NewMat()
+	defer scratch.Close()
+
+	number := 0
+	source := fmt.Sprintf("camera %d", deviceID)
+	if stereo != nil {
+		source = fmt.Sprintf("camera %d+%d (%v)", deviceID, *stereoDevice, mode)
+	}
+
+	// report sends a capture problem to the viewer, returning false once
+	// the viewer is gone.
+	report := func(problem string) bool {
+		select {
+		case imageChan <- capturedImage{problem: problem}:
+			return true
+		case <-done:
+			return false
+		}
+	}
+	// wait pauses before a retry, returning false once the viewer is gone.
+	wait := func(d time.Duration) bool {
+		select {
+		case <-time.After(d):
+			return true
+		case <-done:
+			return false
+		}
+	}
+
+	skipped := 0
+	failures := 0
 	for {
-		webcam.Read(&img)
+		if webcam == nil {
+			vc, err := openCamera()
+			if err != nil {
+				diag.Warn("camera unavailable", "err", err)
+				if !report(fmt.Sprintf("Camera %d unavailable, retrying: %v", deviceID, err)) || !wait(cameraRetryDelay) {
+					return
+				}
+				continue
+			}
+			diag.Info("camera opened", "source", source)
+			webcam = vc
+			skipped, failures = 0, 0
+		}
+
+		applyCameraProperties(webcam)
+		var ok bool
+		if stereo != nil {
+			ok = readStereo(webcam, stereo, &left, &right)
+		} else {
+			ok = webcam.Read(&img)
+		}
+		if !ok || (stereo == nil && img.Empty()) {
+			failures++
+			diag.Warn("camera read failed", "source", source, "failures", failures)
+			if failures == 1 && !report(fmt.Sprintf("Camera read failed on %v, retrying", source)) {
+				return
+			}
+			if failures >= maxReadFailures {
+				diag.Warn("reopening camera", "source", source)
+				metrics.reconnects.Add(1)
+				webcam.Close()
+				webcam = nil
+			}
+			if !wait(readRetryDelay) {
+				return
+			}
+			continue
+		}
+		failures = 0
+
+		if stereo != nil {
+			deinterlace(&left, &scratch, deinterlacing)
+			deinterlace(&right, &scratch, stereoDeinterlacing)
+			if *matchStereo {
+				if cdf, err := computeCDF(left); err == nil {
+					matchHistogram(&right, cdf)
+				}
+			}
+			combineStereo(left, right, &img, mode)
+		} else {
+			deinterlace(&img, &scratch, deinterlacing)
+		}
+		capturedFrames.Add(1)
+		captured := time.Now()
+
+		// cameras ramp exposure and white balance for a moment after opening
+		if skipped < *warmupFrames {
+			skipped++
+			select {
+			case imageChan <- capturedImage{warmup: float32(skipped) / float32(*warmupFrames)}:
+			case <-done:
+				return
+			}
+			continue
+		}
 
-		targetWidth, targetHeight := s.Size()
+		if referenceCDF != nil {
+			matchHistogram(&img, *referenceCDF)
+		}
 
-		targetHeight -= logHeight
+		src := img
+		if rotateFrame(src, &rotated, int(frameRotation.Load())) {
+			src = rotated
+		}
+		if flipImage(src, &flipped, mirrorFrame.Load(), flipFrame.Load()) {
+			src = flipped
+		}
+		if perspective.apply(src, &warped) {
+			src = warped
+		}
 
-		gocv.Resize(img, &small, image.Point{
-			X: targetWidth,
-			Y: targetHeight,
-		}, 0, 0, gocv.InterpolationLinear)
+		targetWidth, targetHeight := captureSize()
+		if targetWidth <= 0 || targetHeight <= 0 {
+			continue
+		}
 
-		smallImage, err := small.ToImage()
+		r, zoomed := visibleRegion(src.Cols(), src.Rows())
+		if zoomed {
+			src = src.Region(r)
+		}
+		sx, sy := int(samplesX.Load()), int(samplesY.Load())
+		smallImage, cols, rows, err := fitFrame(src, &small, &padded, targetWidth, targetHeight, sx, sy, fitMode(frameFit.Load()))
+		if zoomed {
+			src.Close()
+		}
 		if err != nil {
+			diag.Warn("dropping frame", "source", source, "err", err)
 			continue
 		}
 
-		imageChan <- smallImage
+		number++
+		select {
+		case imageChan <- capturedImage{img: smallImage, cols: cols, rows: rows, meta: frameMeta{captured: captured, source: source, number: number}}:
+		case <-done:
+			return
+		}
 	}
 }
 
-func eventListener(s tcell.Screen, eventChan chan<- event) {
+// resizeImage scales a Mat to the given size, runs it through the image
+// filter pipeline and returns it as an image.
+func resizeImage(src gocv.Mat, dst *gocv.Mat, width, height int) (image.Image, error) {
+	size := image.Point{X: width, Y: height}
+	// image filters need color, so gray frames only skip the CPU without them
+	if useGPU && grayFrames.Load() && !imageFiltersActive() && gpuResize(src, dst, size, resizeFlag(), true) {
+		return matToImage(*dst)
+	}
+	resizeMat(src, dst, size)
+	applyImageFilters(dst)
+	return frameImage(dst)
+}
+
+// commandInput is the state of the ':' command prompt.
+type commandInput struct {
+	text      string
+	done      bool
+	cancelled bool
+}
+
+// runCommand executes a command entered at the ':' prompt.
+func runCommand(s tcell.Screen, command string, tut *tutorial) {
+	switch command {
+	case "tutorial":
+		logMessage(s, "")
+		tut.start()
+		tut.draw(s)
+		overlays.draw(s)
+		s.Show()
+	default:
+		logMessage(s, fmt.Sprintf("Unknown command: %v", command))
+	}
+}
+
+func eventListener(s tcell.Screen, eventChan chan<- event, commandChan chan<- commandInput, dragChan chan<- cropDrag) {
+	var focus focusParser
+	var chord chordParser
+	var drag dragParser
+	middlePressed := false
+	var prompt []rune
+	prompting := false
 	for {
 		// Poll event
 		ev := s.PollEvent()
+		if ev == nil {
+			// screen was finalized
+			return
+		}
 
 		// Process event
 		switch ev := ev.(type) {
 		case *tcell.EventResize:
 			eventChan <- resize
+		case *tcell.EventInterrupt:
+			eventChan <- quit
 		case *tcell.EventKey:
-			if ev.Key() == tcell.KeyEscape || ev.Key() == tcell.KeyCtrlC {
-				eventChan <- quit
-			} else if ev.Key() == tcell.KeyRune && ev.Rune() == 'q' {
+			if consumed, valid, focused := focus.feed(ev); consumed {
+				if valid && focused {
+					eventChan <- focusIn
+				} else if valid {
+					eventChan <- focusOut
+				}
+			} else if prompting {
+				switch ev.Key() {
+				case tcell.KeyEnter:
+					prompting = false
+					commandChan <- commandInput{text: string(prompt), done: true}
+				case tcell.KeyEscape, tcell.KeyCtrlC:
+					prompting = false
+					commandChan <- commandInput{cancelled: true}
+				case tcell.KeyBackspace, tcell.KeyBackspace2:
+					if len(prompt) > 0 {
+						prompt = prompt[:len(prompt)-1]
+					}
+					commandChan <- commandInput{text: string(prompt)}
+				case tcell.KeyRune:
+					prompt = append(prompt, ev.Rune())
+					commandChan <- commandInput{text: string(prompt)}
+				}
+			} else if consumed, chordEvent := chord.feed(ev); consumed {
+				eventChan <- chordEvent
+			} else if ev.Key() == tcell.KeyRune && ev.Rune() == ':' {
+				prompting = true
+				prompt = prompt[:0]
+				commandChan <- commandInput{}
+			} else if menuOpen.Load() && ev.Key() == tcell.KeyEscape {
+				eventChan <- menuToggle
+			} else if menuOpen.Load() && ev.Key() == tcell.KeyUp {
+				eventChan <- menuUp
+			} else if menuOpen.Load() && ev.Key() == tcell.KeyDown {
+				eventChan <- menuDown
+			} else if menuOpen.Load() && ev.Key() == tcell.KeyLeft {
+				eventChan <- menuLeft
+			} else if menuOpen.Load() && ev.Key() == tcell.KeyRight {
+				eventChan <- menuRight
+			} else if ev.Key() == tcell.KeyEscape || ev.Key() == tcell.KeyCtrlC {
 				eventChan <- quit
-			} else if ev.Key() == tcell.KeyRune && ev.Rune() == 'c' {
-				eventChan <- colorToggle
-			} else if ev.Key() == tcell.KeyRune && ev.Rune() == 's' {
+			} else if ev.Key() == tcell.KeyPgUp {
+				eventChan <- logScrollUp
+			} else if ev.Key() == tcell.KeyPgDn {
+				eventChan <- logScrollDown
+			} else if ev.Key() == tcell.KeyLeft && perspective.active() {
+				eventChan <- keystoneLeft
+			} else if ev.Key() == tcell.KeyRight && perspective.active() {
+				eventChan <- keystoneRight
+			} else if ev.Key() == tcell.KeyUp && perspective.active() {
+				eventChan <- keystoneUp
+			} else if ev.Key() == tcell.KeyDown && perspective.active() {
+				eventChan <- keystoneDown
+			} else if ev.Key() == tcell.KeyLeft {
+				eventChan <- panLeft
+			} else if ev.Key() == tcell.KeyRight {
+				eventChan <- panRight
+			} else if ev.Key() == tcell.KeyUp {
+				eventChan <- panUp
+			} else if ev.Key() == tcell.KeyDown {
+				eventChan <- panDown
+			} else if ev.Key() == tcell.KeyRune && ev.Rune() >= '1' && ev.Rune() <= '9' {
+				eventChan <- preset1 + event(ev.Rune()-'1')
+			} else if bound, ok := keyBindings[ev.Rune()]; ok && ev.Key() == tcell.KeyRune {
+				eventChan <- bound
+			} else {
+				eventChan <- unboundKey
+			}
+		case *tcell.EventMouse:
+			middle := ev.Buttons()&tcell.Button2 != 0
+			if middle && !middlePressed {
 				eventChan <- screenshot
-			} else if ev.Key() == tcell.KeyRune && ev.Rune() == '+' {
-				eventChan <- increaseBrightness
-			} else if ev.Key() == tcell.KeyRune && ev.Rune() == '-' {
-				eventChan <- decreaseBrightness
+			}
+			middlePressed = middle
+			if d, ok := drag.feed(ev); ok {
+				dragChan <- d
+			} else if ev.Buttons() == tcell.WheelUp {
+				eventChan <- zoomIn
+			} else if ev.Buttons() == tcell.WheelDown {
+				eventChan <- zoomOut
 			}
 		}
 	}
@@ -212,8 +1347,99 @@ type event int
 const (
 	resize event = iota
 	colorToggle
+	pixelToggle
+	edgesToggle
+	glyphToggle
+	ditherCycle
+	charsetCycle
+	invertToggle
+	cvdCycle
+	tintCycle
+	rainToggle
+	falseColorCycle
+	keystoneSelect
+	keystoneReset
+	keystoneLeft
+	keystoneRight
+	keystoneUp
+	keystoneDown
+	cropClear
+	zoomIn
+	zoomOut
+	panLeft
+	panRight
+	panUp
+	panDown
+	exposureToggle
 	increaseBrightness
 	decreaseBrightness
+	increaseContrast
+	decreaseContrast
+	increaseSaturation
+	decreaseSaturation
+	hueLeft
+	hueRight
+	hueCycleToggle
+	warmer
+	cooler
+	negativeToggle
+	nightVisionToggle
+	cartoonToggle
+	claheToggle
+	whiteBalanceToggle
+	flickerCycle
+	increaseOnionSkin
+	decreaseOnionSkin
+	longExposureCycle
+	longExposureReset
+	stackCycle
+	increaseSharpen
+	decreaseSharpen
+	increaseDenoise
+	decreaseDenoise
+	rotateCycle
+	interlacedToggle
+	interpolationCycle
+	pauseToggle
+	stepForward
+	stepBackward
+	fpsToggle
+	resourcesToggle
+	helpToggle
+	// preset1 to preset9 must stay consecutive, the number keys map onto them.
+	preset1
+	preset2
+	preset3
+	preset4
+	preset5
+	preset6
+	preset7
+	preset8
+	preset9
+	logPaneToggle
+	logScrollUp
+	logScrollDown
+	menuToggle
+	menuUp
+	menuDown
+	menuLeft
+	menuRight
+	fitCycle
+	mirrorToggle
+	flipToggle
+	thresholdToggle
+	increaseThreshold
+	decreaseThreshold
+	increasePosterize
+	decreasePosterize
+	chordPending
+	chordUnbound
+	resetAdjustments
+	tutorialStart
 	screenshot
+	recordToggle
+	focusIn
+	focusOut
+	unboundKey
 	quit
 )
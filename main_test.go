@@ -0,0 +1,286 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell"
+)
+
+const (
+	simWidth   = 40
+	simHeight  = 12
+	simTimeout = 2 * time.Second
+)
+
+// simHarness runs the viewer against a simulated terminal and the fake
+// camera, so UI behaviour can be checked end to end without a real
+// terminal or webcam.
+type simHarness struct {
+	sim  tcell.SimulationScreen
+	done chan struct{}
+}
+
+// resetSettings restores the runtime settings a previous case may have
+// changed.
+func resetSettings() {
+	colorEnabled = false
+	pixelEnabled = false
+	edgesEnabled = false
+	glyphEnabled = false
+	dither = ditherNone
+	invertEnabled = false
+	cvd = cvdNone
+	tint = tintNone
+	falseColor = ""
+	resetAdjustmentSettings()
+	colors = colorTrue
+	runes = append([]rune(nil), defaultRunes...)
+	samplesX.Store(1)
+	samplesY.Store(1)
+	perspective.reset()
+	view.reset()
+	frameCrop.clear()
+}
+
+// startHarness starts the viewer with default settings and quits it when
+// the test ends.
+func startHarness(t *testing.T) *simHarness {
+	t.Helper()
+	resetSettings()
+	// keep title and notification escapes off the test output
+	oscOut = io.Discard
+
+	sim := tcell.NewSimulationScreen("UTF-8")
+	if err := sim.Init(); err != nil {
+		t.Fatal(err)
+	}
+	sim.SetSize(simWidth, simHeight)
+
+	h := &simHarness{sim: sim, done: make(chan struct{})}
+	go func() {
+		runViewer(sim, tcell.StyleDefault, "", false, fakeCamera())
+		close(h.done)
+	}()
+	t.Cleanup(func() {
+		if err := h.stop(); err != nil {
+			t.Error(err)
+		}
+	})
+	return h
+}
+
+// key injects a key press for a printable rune.
+func (h *simHarness) key(r rune) {
+	h.sim.InjectKey(tcell.KeyRune, r, tcell.ModNone)
+}
+
+// typeText injects a sequence of printable runes.
+func (h *simHarness) typeText(text string) {
+	for _, r := range text {
+		h.key(r)
+	}
+}
+
+// row returns the text currently shown on screen row y.
+func (h *simHarness) row(y int) string {
+	cells, width, _ := h.sim.GetContents()
+	var sb strings.Builder
+	for x := 0; x < width; x++ {
+		runes := cells[y*width+x].Runes
+		if len(runes) == 0 {
+			sb.WriteRune(' ')
+		} else {
+			sb.WriteRune(runes[0])
+		}
+	}
+	return sb.String()
+}
+
+// cell returns the rune and style shown at x, y.
+func (h *simHarness) cell(x, y int) (rune, tcell.Style) {
+	cells, width, _ := h.sim.GetContents()
+	c := cells[y*width+x]
+	if len(c.Runes) == 0 {
+		return ' ', c.Style
+	}
+	return c.Runes[0], c.Style
+}
+
+// waitFor polls until cond holds or the timeout expires.
+func (h *simHarness) waitFor(what string, cond func() bool) error {
+	deadline := time.Now().Add(simTimeout)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return nil
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return fmt.Errorf("timed out waiting for %v", what)
+}
+
+// waitForLog waits for the log line to contain text.
+func (h *simHarness) waitForLog(text string) error {
+	return h.waitFor(fmt.Sprintf("log line %q", text), func() bool {
+		return strings.Contains(h.row(simHeight-1), text)
+	})
+}
+
+// stop quits the viewer and finalizes the simulated screen.
+func (h *simHarness) stop() error {
+	defer h.sim.Fini()
+	h.key('q')
+	select {
+	case <-h.done:
+		return nil
+	case <-time.After(simTimeout):
+		return fmt.Errorf("viewer did not quit")
+	}
+}
+
+func TestRendersGradient(t *testing.T) {
+	h := startHarness(t)
+	if err := h.waitFor("dark left and bright right edge", func() bool {
+		left, _ := h.cell(0, 0)
+		right, _ := h.cell(simWidth-1, 0)
+		return left == ' ' && right == defaultRunes[len(defaultRunes)-1]
+	}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestInvertedRamp(t *testing.T) {
+	h := startHarness(t)
+	h.key('i')
+	if err := h.waitFor("bright left and dark right edge", func() bool {
+		left, _ := h.cell(0, 0)
+		right, _ := h.cell(simWidth-1, 0)
+		return left == defaultRunes[len(defaultRunes)-1] && right == ' '
+	}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBrightnessReadout(t *testing.T) {
+	h := startHarness(t)
+	h.key('+')
+	h.key(']')
+	if err := h.waitForLog("Brightness: +0.05  Contrast: 1.1"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNegativeFilter(t *testing.T) {
+	h := startHarness(t)
+	h.key('n')
+	if err := h.waitFor("bright left and dark right edge", func() bool {
+		left, _ := h.cell(0, 0)
+		right, _ := h.cell(simWidth-1, 0)
+		return left == defaultRunes[len(defaultRunes)-1] && right == ' '
+	}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestThresholdMode(t *testing.T) {
+	h := startHarness(t)
+	h.key('b')
+	dense := defaultRunes[len(defaultRunes)-1]
+	if err := h.waitFor("only blank and dense glyphs", func() bool {
+		row := h.row(0)
+		for _, r := range row {
+			if r != ' ' && r != dense {
+				return false
+			}
+		}
+		return strings.ContainsRune(row, dense)
+	}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLeaderKeyChord(t *testing.T) {
+	h := startHarness(t)
+	h.key('+')
+	if err := h.waitForLog("Brightness: +0.05  Contrast: 1.0"); err != nil {
+		t.Fatal(err)
+	}
+	h.key(' ')
+	h.key('r')
+	if err := h.waitForLog("Image adjustments reset"); err != nil {
+		t.Fatal(err)
+	}
+	h.key('+')
+	if err := h.waitForLog("Brightness: +0.05  Contrast: 1.0"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLogLine(t *testing.T) {
+	h := startHarness(t)
+	h.key('e')
+	if err := h.waitForLog("Edge Mode Toggle"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestColorToggle(t *testing.T) {
+	h := startHarness(t)
+	h.key('c')
+	if err := h.waitForLog("Color Toggle"); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.waitFor("colored foreground", func() bool {
+		_, style := h.cell(simWidth-1, 0)
+		fg, _, _ := style.Decompose()
+		return fg != tcell.ColorDefault
+	}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPixelMode(t *testing.T) {
+	h := startHarness(t)
+	h.key('p')
+	if err := h.waitFor("background colored blank cells", func() bool {
+		r, style := h.cell(simWidth-1, 0)
+		_, bg, _ := style.Decompose()
+		return r == ' ' && bg != tcell.ColorDefault
+	}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCommandPrompt(t *testing.T) {
+	h := startHarness(t)
+	h.key(':')
+	h.typeText("tutorial")
+	if err := h.waitForLog(":tutorial"); err != nil {
+		t.Fatal(err)
+	}
+	h.sim.InjectKey(tcell.KeyEnter, 0, tcell.ModNone)
+	if err := h.waitFor("tutorial overlay", func() bool {
+		return strings.Contains(h.row(0), "Welcome")
+	}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTutorialAdvancesOnRealEvents(t *testing.T) {
+	h := startHarness(t)
+	h.key(':')
+	h.typeText("tutorial")
+	h.sim.InjectKey(tcell.KeyEnter, 0, tcell.ModNone)
+	if err := h.waitFor("tutorial overlay", func() bool { return strings.Contains(h.row(0), "Welcome") }); err != nil {
+		t.Fatal(err)
+	}
+	h.key('c')
+	if err := h.waitFor("second tutorial step", func() bool {
+		return strings.Contains(h.row(0), tutorialSteps[1].render()[:20])
+	}); err != nil {
+		t.Fatal(err)
+	}
+}
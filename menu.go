@@ -0,0 +1,224 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync/atomic"
+
+	"github.com/gdamore/tcell"
+)
+
+// menuLayer is the z-order of the settings menu, above the tutorial.
+const menuLayer = 25
+
+// menuOpen tells the event listener to route the arrow keys to the
+// settings menu.
+var menuOpen atomic.Bool
+
+// menuRamps are the glyph ramps the settings menu cycles through.
+var menuRamps = [][]rune{
+	defaultRunes,
+	[]rune(" .:-=+*#%@"),
+	[]rune(" ░▒▓█"),
+	[]rune(" .oO0@"),
+}
+
+// nextRamp switches to the ramp dir entries after the current one in
+// menuRamps. A custom ramp is treated as if it came before the first entry.
+func nextRamp(dir int) {
+	i := slices.IndexFunc(menuRamps, func(r []rune) bool { return slices.Equal(r, runes) })
+	if i < 0 && dir > 0 {
+		i = -1
+	} else if i < 0 {
+		i = 0
+	}
+	runes = append([]rune(nil), menuRamps[cycle(i, len(menuRamps), dir)]...)
+}
+
+func rampReadout() string {
+	return fmt.Sprintf("Ramp: %q", strings.TrimLeft(string(runes), " "))
+}
+
+// menuItem is one row of the settings menu.
+type menuItem struct {
+	label string
+	value func() string
+	// adjust changes the setting by one step in direction dir, -1 or 1.
+	adjust func(dir int)
+}
+
+// settingsMenu is a panel of settings adjusted with the arrow keys, with
+// the video updating live underneath.
+type settingsMenu struct {
+	items    []menuItem
+	selected int
+}
+
+// cycle moves index i of n choices by dir, wrapping around.
+func cycle(i, n, dir int) int {
+	return ((i+dir)%n + n) % n
+}
+
+// onOff describes a boolean setting.
+func onOff(v bool) string {
+	if v {
+		return "on"
+	}
+	return "off"
+}
+
+// toggleItem is a menu item for a boolean setting.
+func toggleItem(label string, v *bool) menuItem {
+	return menuItem{
+		label:  label,
+		value:  func() string { return onOff(*v) },
+		adjust: func(int) { *v = !*v },
+	}
+}
+
+// imageFilterItem is a menu item toggling an image filter.
+func imageFilterItem(label string, f imageFilter) menuItem {
+	return menuItem{
+		label:  label,
+		value:  func() string { return onOff(imageFilterActive(f.name())) },
+		adjust: func(int) { toggleImageFilter(f) },
+	}
+}
+
+// readoutValue strips the label from a readout such as "Sharpen: 0.5".
+func readoutValue(readout string) string {
+	if _, v, ok := strings.Cut(readout, ": "); ok {
+		return v
+	}
+	return readout
+}
+
+func newSettingsMenu() *settingsMenu {
+	items := []menuItem{
+		{
+			label:  "Ramp",
+			value:  func() string { return fmt.Sprintf("%q", strings.TrimLeft(string(runes), " ")) },
+			adjust: nextRamp,
+		},
+		toggleItem("Color", &colorEnabled),
+		{
+			label: "Color depth",
+			value: func() string { return colors.String() },
+			// auto is only meaningful at startup
+			adjust: func(dir int) {
+				colors = colorDepth(1 + cycle(int(colors)-1, len(colorDepthNames)-1, dir))
+			},
+		},
+		toggleItem("Pixel mode", &pixelEnabled),
+		{
+			label: "Glyph matching",
+			value: func() string { return onOff(glyphEnabled) },
+			adjust: func(int) {
+				glyphEnabled = !glyphEnabled
+				updateSamples()
+			},
+		},
+		toggleItem("Edges", &edgesEnabled),
+		toggleItem("Invert", &invertEnabled),
+		{
+			label:  "Dithering",
+			value:  func() string { return dither.String() },
+			adjust: func(dir int) { dither = ditherMode(cycle(int(dither), len(ditherNames), dir)) },
+		},
+		{
+			label:  "Tint",
+			value:  func() string { return tint.String() },
+			adjust: func(dir int) { tint = tintMode(cycle(int(tint), len(tintNames), dir)) },
+		},
+		{
+			label:  "Brightness",
+			value:  func() string { return fmt.Sprintf("%+.2f", brightnessOffset) },
+			adjust: changeBrightness,
+		},
+		{
+			label:  "Contrast",
+			value:  func() string { return fmt.Sprintf("%.1f", contrastGain) },
+			adjust: changeContrast,
+		},
+		toggleItem("Auto exposure", &autoExposure),
+		{
+			label:  "Saturation",
+			value:  func() string { return readoutValue(saturationReadout()) },
+			adjust: changeSaturation,
+		},
+		{
+			label:  "Temperature",
+			value:  func() string { return readoutValue(temperatureReadout()) },
+			adjust: changeTemperature,
+		},
+		{
+			label:  "Sharpen",
+			value:  func() string { return readoutValue(sharpenReadout()) },
+			adjust: changeSharpen,
+		},
+		{
+			label:  "Denoise",
+			value:  func() string { return readoutValue(denoiseReadout()) },
+			adjust: changeDenoise,
+		},
+		imageFilterItem("White balance", whiteBalanceFilter{}),
+		imageFilterItem("Local contrast", claheFilter{}),
+		imageFilterItem("Cartoon", cartoonFilter{}),
+	}
+	for _, c := range cameraControls {
+		items = append(items, menuItem{
+			label:  c.label,
+			value:  func() string { return cameraPropertyReadout(c.prop) },
+			adjust: func(dir int) { changeCameraProperty(c.prop, dir) },
+		})
+	}
+	return &settingsMenu{items: items}
+}
+
+// move selects the item dir rows down, wrapping around.
+func (m *settingsMenu) move(dir int) {
+	m.selected = cycle(m.selected, len(m.items), dir)
+}
+
+// adjust changes the selected setting and returns a readout of it.
+func (m *settingsMenu) adjust(dir int) string {
+	item := m.items[m.selected]
+	item.adjust(dir)
+	return fmt.Sprintf("%v: %v", item.label, item.value())
+}
+
+// draw shows the menu panel in the top left corner.
+func (m *settingsMenu) draw() {
+	const title = " Settings  up/down select  left/right change "
+	labelWidth := 0
+	for _, item := range m.items {
+		labelWidth = max(labelWidth, len(item.label))
+	}
+	rows := []string{title}
+	for _, item := range m.items {
+		rows = append(rows, fmt.Sprintf(" %-*s  %v ", labelWidth, item.label, item.value()))
+	}
+	width := 0
+	for _, row := range rows {
+		width = max(width, len([]rune(row)))
+	}
+
+	sf := overlays.surface("menu", menuLayer)
+	sf.clear()
+	base := tcell.StyleDefault.Background(tcell.ColorBlack).Foreground(tcell.ColorWhite)
+	for y, row := range rows {
+		style := base
+		if y == 0 {
+			style = base.Foreground(palette.highlight)
+		} else if y-1 == m.selected {
+			style = base.Reverse(true)
+		}
+		sf.text(0, y, fmt.Sprintf("%-*s", width, row), style, 0.9)
+	}
+}
+
+// closeMenu hides the menu panel.
+func closeMenu() {
+	overlays.remove("menu")
+}
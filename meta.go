@@ -0,0 +1,67 @@
+package main
+
+import (
+	"image"
+	"time"
+)
+
+// sceneCutThreshold is the fraction of changed cells between two frames
+// that starts a new scene.
+const sceneCutThreshold = 0.6
+
+// frameMeta travels with a frame from capture through conversion to the
+// overlays, exports and servers, so they all describe a frame the same way.
+type frameMeta struct {
+	// captured is when the source image was read.
+	captured time.Time
+	// source names the device or file the image came from.
+	source string
+	// number counts the frames read from the source, starting at 1.
+	number int
+	// scene counts the cuts seen so far; frames of one scene share it.
+	scene int
+	// detections are the things detectors found in the frame.
+	detections []detection
+}
+
+// detection is something found in a frame, located in cells.
+type detection struct {
+	label string
+	box   image.Rectangle
+	score float32
+}
+
+// frameChange returns the fraction of cells whose brightness changed
+// noticeably from prev to f and the bounds of those cells, or 0 if the
+// frames cannot be compared.
+func frameChange(prev, f *frame) (float32, image.Rectangle) {
+	if prev == nil || prev.width != f.width || prev.height != f.height || len(f.cells) == 0 {
+		return 0, image.Rectangle{}
+	}
+	changed := 0
+	var bounds image.Rectangle
+	for y := 0; y < f.height; y++ {
+		for x := 0; x < f.width; x++ {
+			d := brightness(f.at(x, y).color) - brightness(prev.at(x, y).color)
+			if d > motionCellDelta || d < -motionCellDelta {
+				changed++
+				bounds = bounds.Union(image.Rect(x, y, x+1, y+1))
+			}
+		}
+	}
+	return float32(changed) / float32(len(f.cells)), bounds
+}
+
+// sceneTracker numbers scenes by counting cuts.
+type sceneTracker struct {
+	scene int
+}
+
+// feed takes the change from the previous frame and returns the scene of
+// the new frame.
+func (t *sceneTracker) feed(change float32) int {
+	if change >= sceneCutThreshold {
+		t.scene++
+	}
+	return t.scene
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sync/atomic"
+	"time"
+)
+
+// conversionBuckets are the upper bounds, in seconds, of the conversion
+// latency histogram.
+var conversionBuckets = []float64{0.001, 0.002, 0.005, 0.01, 0.02, 0.05, 0.1, 0.25}
+
+// latencyHistogram counts durations into cumulative buckets, in the shape
+// Prometheus expects. It is safe for concurrent use.
+type latencyHistogram struct {
+	bounds  []float64
+	buckets []atomic.Uint64
+	count   atomic.Uint64
+	nanos   atomic.Int64
+}
+
+func newLatencyHistogram(bounds []float64) *latencyHistogram {
+	return &latencyHistogram{bounds: bounds, buckets: make([]atomic.Uint64, len(bounds))}
+}
+
+func (h *latencyHistogram) observe(d time.Duration) {
+	for i, bound := range h.bounds {
+		if d.Seconds() <= bound {
+			h.buckets[i].Add(1)
+		}
+	}
+	h.count.Add(1)
+	h.nanos.Add(int64(d))
+}
+
+// metrics counts what happens to frames, for monitoring the viewer as a
+// long-running service.
+var metrics = struct {
+	rendered   atomic.Uint64
+	dropped    atomic.Uint64
+	reconnects atomic.Uint64
+	conversion *latencyHistogram
+}{conversion: newLatencyHistogram(conversionBuckets)}
+
+// writeMetrics writes the metrics in the Prometheus text format.
+func writeMetrics(w io.Writer) {
+	counter := func(name, help string, v uint64) {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n%s %d\n", name, help, name, name, v)
+	}
+	counter("ascii_webcam_frames_captured_total", "Frames read from the camera.", uint64(capturedFrames.Load()))
+	counter("ascii_webcam_frames_rendered_total", "Frames converted and drawn.", metrics.rendered.Load())
+	counter("ascii_webcam_frames_dropped_total", "Frames dropped before drawing, while paused, unfocused or behind.", metrics.dropped.Load())
+	counter("ascii_webcam_camera_reconnects_total", "Times the camera was reopened after failing.", metrics.reconnects.Load())
+
+	const name = "ascii_webcam_conversion_seconds"
+	h := metrics.conversion
+	fmt.Fprintf(w, "# HELP %s Time to convert a frame to cells.\n# TYPE %s histogram\n", name, name)
+	for i, bound := range h.bounds {
+		fmt.Fprintf(w, "%s_bucket{le=\"%g\"} %d\n", name, bound, h.buckets[i].Load())
+	}
+	count := h.count.Load()
+	fmt.Fprintf(w, "%s_bucket{le=\"+Inf\"} %d\n", name, count)
+	fmt.Fprintf(w, "%s_sum %g\n", name, time.Duration(h.nanos.Load()).Seconds())
+	fmt.Fprintf(w, "%s_count %d\n", name, count)
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+
+	"gocv.io/x/gocv"
+)
+
+const (
+	maxOnionSkin = 8
+	// onionSkinDecay is how much of its weight a frame keeps each time a
+	// newer one is blended over it.
+	onionSkinDecay = 0.6
+)
+
+// onionSkinFrames is the number of previous frames blended into the
+// current one, or 0 when onion skinning is off.
+var onionSkinFrames int
+
+// onionSkinHistory holds copies of the most recent frames, oldest first.
+// It is only touched with imageFilters.mu held.
+var onionSkinHistory []gocv.Mat
+
+// onionSkinFilter blends the previous frames into the current one with
+// decaying alpha, so moving objects leave fading trails behind them like
+// the stacked drawings of an animator's light table.
+type onionSkinFilter struct {
+	frames int
+}
+
+func (onionSkinFilter) name() string { return "onion skin" }
+
+func (f onionSkinFilter) apply(img *gocv.Mat) {
+	if len(onionSkinHistory) > 0 {
+		prev := onionSkinHistory[0]
+		if prev.Rows() != img.Rows() || prev.Cols() != img.Cols() || prev.Type() != img.Type() {
+			closeOnionSkinHistory()
+		}
+	}
+
+	current := img.Clone()
+	if len(onionSkinHistory) > 0 {
+		onionSkinHistory[0].CopyTo(img)
+		for _, m := range onionSkinHistory[1:] {
+			gocv.AddWeighted(*img, onionSkinDecay, m, 1-onionSkinDecay, 0, img)
+		}
+		gocv.AddWeighted(*img, onionSkinDecay, current, 1-onionSkinDecay, 0, img)
+	}
+
+	onionSkinHistory = append(onionSkinHistory, current)
+	for len(onionSkinHistory) > f.frames {
+		onionSkinHistory[0].Close()
+		onionSkinHistory = onionSkinHistory[1:]
+	}
+}
+
+// closeOnionSkinHistory drops the stored frames.
+func closeOnionSkinHistory() {
+	for _, m := range onionSkinHistory {
+		m.Close()
+	}
+	onionSkinHistory = nil
+}
+
+// setOnionSkin sets how many previous frames are blended in, turning
+// onion skinning off at 0.
+func setOnionSkin(frames int) {
+	onionSkinFrames = min(max(frames, 0), maxOnionSkin)
+	if onionSkinFrames == 0 {
+		removeImageFilter(onionSkinFilter{}.name())
+		// forget the trails so they do not reappear when turned back on
+		imageFilters.mu.Lock()
+		closeOnionSkinHistory()
+		imageFilters.mu.Unlock()
+		return
+	}
+	setImageFilter(onionSkinFilter{frames: onionSkinFrames})
+}
+
+// changeOnionSkin adds steps frames to the onion skin.
+func changeOnionSkin(steps int) {
+	setOnionSkin(onionSkinFrames + steps)
+}
+
+func onionSkinReadout() string {
+	if onionSkinFrames == 0 {
+		return "Onion Skin: off"
+	}
+	return fmt.Sprintf("Onion Skin: %d frames", onionSkinFrames)
+}
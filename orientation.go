@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"sync/atomic"
+
+	"gocv.io/x/gocv"
+)
+
+const (
+	// OpenCV's CAP_PROP_ORIENTATION_META and CAP_PROP_ORIENTATION_AUTO,
+	// which gocv does not define.
+	videoCaptureOrientationMeta gocv.VideoCaptureProperties = 48
+	videoCaptureOrientationAuto gocv.VideoCaptureProperties = 49
+)
+
+// rotationSetting is the --rotate flag: a clockwise rotation in degrees,
+// or rotationAuto to use the camera's orientation metadata.
+type rotationSetting int
+
+const rotationAuto rotationSetting = -1
+
+func (r rotationSetting) String() string {
+	if r == rotationAuto {
+		return "auto"
+	}
+	return strconv.Itoa(int(r))
+}
+
+// Set implements flag.Value.
+func (r *rotationSetting) Set(value string) error {
+	if value == "auto" {
+		*r = rotationAuto
+		return nil
+	}
+	degrees, err := strconv.Atoi(value)
+	if err != nil || degrees%90 != 0 {
+		return fmt.Errorf("rotation must be auto or a multiple of 90, got %q", value)
+	}
+	*r = rotationSetting((degrees%360 + 360) % 360)
+	return nil
+}
+
+// frameRotation is the clockwise rotation applied by the capture goroutine.
+var frameRotation atomic.Int32
+
+// detectRotation reads orientation hints from a capture device, returning
+// the clockwise rotation needed to show frames upright. OpenCV's own
+// auto-rotation is turned off so the rotation is applied exactly once.
+func detectRotation(vc *gocv.VideoCapture) int {
+	vc.Set(videoCaptureOrientationAuto, 0)
+
+	for _, prop := range []gocv.VideoCaptureProperties{videoCaptureOrientationMeta, gocv.VideoCaptureRoll} {
+		degrees := vc.Get(prop)
+		if degrees == 0 || math.IsNaN(degrees) {
+			continue
+		}
+		quarter := int(math.Round(degrees/90)) * 90
+		return (quarter%360 + 360) % 360
+	}
+	return 0
+}
+
+// rotateFrame rotates src clockwise by degrees into dst. It returns false,
+// leaving dst untouched, when no rotation is needed.
+func rotateFrame(src gocv.Mat, dst *gocv.Mat, degrees int) bool {
+	if src.Empty() {
+		return false
+	}
+	switch degrees {
+	case 90:
+		gocv.Rotate(src, dst, gocv.Rotate90Clockwise)
+	case 180:
+		gocv.Rotate(src, dst, gocv.Rotate180Clockwise)
+	case 270:
+		gocv.Rotate(src, dst, gocv.Rotate90CounterClockwise)
+	default:
+		return false
+	}
+	return true
+}
+
+// mirrorFrame and flipFrame flip frames horizontally and vertically in the
+// capture goroutine. Webcams show the scene as others see it, so mirroring
+// makes the viewer behave like a mirror.
+var (
+	mirrorFrame atomic.Bool
+	flipFrame   atomic.Bool
+)
+
+// flipImage flips src into dst as requested. It returns false, leaving dst
+// untouched, when neither flip is needed.
+func flipImage(src gocv.Mat, dst *gocv.Mat, horizontal, vertical bool) bool {
+	if src.Empty() {
+		return false
+	}
+	switch {
+	case horizontal && vertical:
+		gocv.Flip(src, dst, -1)
+	case horizontal:
+		gocv.Flip(src, dst, 1)
+	case vertical:
+		gocv.Flip(src, dst, 0)
+	default:
+		return false
+	}
+	return true
+}
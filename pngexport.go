@@ -0,0 +1,136 @@
+//go:build !minimal
+
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"time"
+
+	"gocv.io/x/gocv"
+)
+
+const (
+	// rasterCellWidth and rasterCellHeight are the size of one cell in
+	// rasterized frames.
+	rasterCellWidth  = 10
+	rasterCellHeight = 20
+	// rasterBaseline is the baseline of glyphs from the top of their cell.
+	rasterBaseline = 14
+	rasterFont     = gocv.FontHersheyPlain
+	rasterScale    = 1.0
+)
+
+func init() {
+	screenshotFormats["png"] = writePNGScreenshot
+}
+
+// writePNGScreenshot writes a screenshot as a PNG image.
+func writePNGScreenshot(w io.Writer, sf *shownFrame) (string, error) {
+	data, err := rasterizeFrame(sf)
+	if err != nil {
+		return "", err
+	}
+	_, err = w.Write(data)
+	return ".png", err
+}
+
+// rasterizeFrame rasterizes sf and returns it encoded as PNG.
+func rasterizeFrame(sf *shownFrame) ([]byte, error) {
+	img := rasterizeMat(sf)
+	defer img.Close()
+
+	buf, err := gocv.IMEncode(gocv.PNGFileExt, img)
+	if err != nil {
+		return nil, err
+	}
+	defer buf.Close()
+	return append([]byte(nil), buf.GetBytes()...), nil
+}
+
+// rasterizeMat draws the glyph grid of sf with OpenCV's built-in Hershey
+// font, each glyph centered in its cell. The caller closes the result.
+func rasterizeMat(sf *shownFrame) gocv.Mat {
+	img := gocv.NewMatWithSizeFromScalar(gocv.NewScalar(0, 0, 0, 0), sf.height*rasterCellHeight, sf.width*rasterCellWidth, gocv.MatTypeCV8UC3)
+
+	for y := 0; y < sf.height; y++ {
+		for x := 0; x < sf.width; x++ {
+			c := sf.at(x, y)
+			fg, bg := exportForeground, exportBackground
+			if c.fg.A > 0 {
+				fg = c.fg
+			}
+			if c.bg.A > 0 {
+				bg = c.bg
+			}
+			rasterizeCell(&img, image.Rect(x*rasterCellWidth, y*rasterCellHeight, (x+1)*rasterCellWidth, (y+1)*rasterCellHeight), c.r, fg, bg)
+		}
+	}
+	return img
+}
+
+// rasterizeCell draws one glyph over its background. Block elements, which
+// the Hershey font lacks, are drawn as rectangles and shades.
+func rasterizeCell(img *gocv.Mat, r image.Rectangle, glyph rune, fg, bg color.RGBA) {
+	gocv.Rectangle(img, r, bg, -1)
+
+	mid := r.Min.Add(r.Size().Div(2))
+	switch glyph {
+	case ' ':
+	case '█':
+		gocv.Rectangle(img, r, fg, -1)
+	case '▀':
+		gocv.Rectangle(img, image.Rect(r.Min.X, r.Min.Y, r.Max.X, mid.Y), fg, -1)
+	case '▄':
+		gocv.Rectangle(img, image.Rect(r.Min.X, mid.Y, r.Max.X, r.Max.Y), fg, -1)
+	case '▌':
+		gocv.Rectangle(img, image.Rect(r.Min.X, r.Min.Y, mid.X, r.Max.Y), fg, -1)
+	case '▐':
+		gocv.Rectangle(img, image.Rect(mid.X, r.Min.Y, r.Max.X, r.Max.Y), fg, -1)
+	case '░':
+		gocv.Rectangle(img, r, mixColors(fg, bg, 0.25), -1)
+	case '▒':
+		gocv.Rectangle(img, r, mixColors(fg, bg, 0.5), -1)
+	case '▓':
+		gocv.Rectangle(img, r, mixColors(fg, bg, 0.75), -1)
+	default:
+		text := string(glyph)
+		size := gocv.GetTextSize(text, rasterFont, rasterScale, 1)
+		org := image.Pt(r.Min.X+(rasterCellWidth-size.X)/2, r.Min.Y+rasterBaseline)
+		gocv.PutTextWithParams(img, text, org, rasterFont, rasterScale, fg, 1, gocv.LineAA, false)
+	}
+}
+
+// mixColors returns a color the given fraction of the way from b to a.
+func mixColors(a, b color.RGBA, fraction float32) color.RGBA {
+	mix := func(x, y uint8) uint8 {
+		return clampChannel(float32(x)*fraction + float32(y)*(1-fraction))
+	}
+	return color.RGBA{mix(a.R, b.R), mix(a.G, b.G), mix(a.B, b.B), 255}
+}
+
+// pngWriter exports a single frame as a PNG image.
+type pngWriter struct {
+	w      io.Writer
+	color  bool
+	frames int
+}
+
+func (pw *pngWriter) writeFrame(f *frame, at time.Duration) error {
+	if pw.frames > 0 {
+		return fmt.Errorf("a PNG holds a single frame, export videos as .cast, .gif or .mp4")
+	}
+	pw.frames++
+	data, err := rasterizeFrame(newShownFrame(f, pw.color))
+	if err != nil {
+		return err
+	}
+	_, err = pw.w.Write(data)
+	return err
+}
+
+func (pw *pngWriter) close() error {
+	return nil
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+)
+
+const maxPosterizeLevels = 16
+
+// posterizeLevels is the number of levels per channel of the posterize
+// filter, or 0 when it is off.
+var posterizeLevels = 0
+
+// posterizeFilter reduces each channel to a fixed number of evenly spaced
+// levels for a flat, print-like look.
+type posterizeFilter struct {
+	levels int
+}
+
+func (posterizeFilter) name() string { return "posterize" }
+
+func (p posterizeFilter) apply(c color.RGBA) color.RGBA {
+	quantize := func(v uint8) uint8 {
+		step := 255 / float32(p.levels-1)
+		return uint8(float32(int(float32(v)/step+0.5)) * step)
+	}
+	return color.RGBA{quantize(c.R), quantize(c.G), quantize(c.B), c.A}
+}
+
+// changePosterize changes the number of posterize levels by steps. Going
+// below two levels turns the filter off, and stepping up from off starts
+// at two.
+func changePosterize(steps int) {
+	switch {
+	case posterizeLevels == 0 && steps > 0:
+		posterizeLevels = 1 + steps
+	case posterizeLevels != 0:
+		posterizeLevels += steps
+	}
+	posterizeLevels = min(posterizeLevels, maxPosterizeLevels)
+	if posterizeLevels < 2 {
+		posterizeLevels = 0
+		removeFilter(posterizeFilter{}.name())
+		return
+	}
+	setFilter(posterizeFilter{levels: posterizeLevels})
+}
+
+func posterizeReadout() string {
+	if posterizeLevels == 0 {
+		return "Posterize: off"
+	}
+	return fmt.Sprintf("Posterize: %d levels", posterizeLevels)
+}
@@ -0,0 +1,27 @@
+//go:build !minimal
+
+package main
+
+import (
+	"net"
+	"net/http"
+	"net/http/pprof"
+)
+
+// startProfiler serves net/http/pprof on addr in the background, so the
+// running viewer can be profiled with go tool pprof.
+func startProfiler(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	go http.Serve(listener, mux)
+	return nil
+}
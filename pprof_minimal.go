@@ -0,0 +1,12 @@
+//go:build minimal
+
+package main
+
+import (
+	"errors"
+)
+
+// startProfiler fails in the minimal build, which has no HTTP server.
+func startProfiler(addr string) error {
+	return errors.New("the profiler is not included in the minimal build")
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// maxPresets is the number of presets, selected with the keys 1 to 9.
+const maxPresets = 9
+
+// settingsPreset is a named combination of settings from a
+// [presets.<name>] section of the config file, such as
+//
+//	[presets.night]
+//	key = 2
+//	charset = " .:-=+*#%@"
+//	color = false
+//	tint = "green"
+//	filters = ["clahe", "night-vision"]
+//
+// Settings a preset leaves out keep their current values. Presets without
+// a key take the free numbers in the order of their names.
+type settingsPreset struct {
+	name     string
+	settings []func()
+}
+
+// presets holds the preset of each number key, 1 at index 0.
+var presets [maxPresets]*settingsPreset
+
+// activePreset is the name of the last preset applied, for the status bar.
+var activePreset = ""
+
+// presetFilter is a filter presets can switch.
+type presetFilter struct {
+	name    string
+	on, off func()
+	active  func() bool
+}
+
+// presetFilters are the filters a preset's filters list can turn on. The
+// list replaces all of them, so the ones not listed are turned off.
+var presetFilters = []presetFilter{
+	{"negative", func() { setFilter(negativeFilter{}) }, func() { removeFilter(negativeFilter{}.name()) }, func() bool { return filterActive(negativeFilter{}.name()) }},
+	{"night-vision", func() { setNightVision(true) }, func() { setNightVision(false) }, func() bool { return filterActive(nightVisionPreset.filters[0].name()) }},
+	{"white-balance", func() { setImageFilter(whiteBalanceFilter{}) }, func() { removeImageFilter(whiteBalanceFilter{}.name()) }, func() bool { return imageFilterActive(whiteBalanceFilter{}.name()) }},
+	{"clahe", func() { setImageFilter(claheFilter{}) }, func() { removeImageFilter(claheFilter{}.name()) }, func() bool { return imageFilterActive(claheFilter{}.name()) }},
+	{"cartoon", func() { setImageFilter(cartoonFilter{}) }, func() { removeImageFilter(cartoonFilter{}.name()) }, func() bool { return imageFilterActive(cartoonFilter{}.name()) }},
+}
+
+// setNightVision turns the night vision filters on or off.
+func setNightVision(on bool) {
+	for _, f := range nightVisionPreset.filters {
+		if on {
+			setFilter(f)
+		} else {
+			removeFilter(f.name())
+		}
+	}
+}
+
+// configurePresets reads the [presets.<name>] sections of cfg.
+func configurePresets(cfg *config) error {
+	var names []string
+	for section := range cfg.sections {
+		if name, ok := strings.CutPrefix(section, "presets."); ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var unnumbered []*settingsPreset
+	for _, name := range names {
+		p, key, err := parsePreset(name, cfg.section("presets."+name))
+		if err != nil {
+			return fmt.Errorf("%v: preset %v: %v", cfg.path, name, err)
+		}
+		if key == 0 {
+			unnumbered = append(unnumbered, p)
+			continue
+		}
+		if presets[key-1] != nil {
+			return fmt.Errorf("%v: presets %v and %v both use key %d", cfg.path, presets[key-1].name, name, key)
+		}
+		presets[key-1] = p
+	}
+	for i := range presets {
+		if presets[i] == nil && len(unnumbered) > 0 {
+			presets[i], unnumbered = unnumbered[0], unnumbered[1:]
+		}
+	}
+	if len(unnumbered) > 0 {
+		return fmt.Errorf("%v: more than %d presets", cfg.path, maxPresets)
+	}
+	return nil
+}
+
+// parsePreset turns the settings of a preset section into setters,
+// returning the key number it asks for or 0.
+func parsePreset(name string, section map[string]any) (*settingsPreset, int, error) {
+	p := &settingsPreset{name: name}
+	key := 0
+	for k, v := range section {
+		var set func()
+		var err error
+		switch k {
+		case "key":
+			n, ok := v.(int64)
+			if !ok || n < 1 || n > maxPresets {
+				return nil, 0, fmt.Errorf("key must be a number from 1 to %d", maxPresets)
+			}
+			key = int(n)
+			continue
+		case "charset":
+			text, ok := v.(string)
+			if !ok || len([]rune(text)) < 2 {
+				return nil, 0, fmt.Errorf("charset needs at least two glyphs")
+			}
+			set = func() { runes = []rune(text) }
+		case "color":
+			set, err = presetBool(k, v, &colorEnabled)
+		case "pixel":
+			set, err = presetBool(k, v, &pixelEnabled)
+		case "edges":
+			set, err = presetBool(k, v, &edgesEnabled)
+		case "invert":
+			set, err = presetBool(k, v, &invertEnabled)
+		case "glyphs":
+			if set, err = presetBool(k, v, &glyphEnabled); err == nil {
+				setGlyphs := set
+				set = func() {
+					setGlyphs()
+					updateSamples()
+				}
+			}
+		case "colors":
+			var depth colorDepth
+			if err = presetEnum(k, v, &depth); err == nil && depth == colorAuto {
+				err = fmt.Errorf("colors cannot be auto in a preset")
+			}
+			set = func() { colors = depth }
+		case "dither":
+			var mode ditherMode
+			err = presetEnum(k, v, &mode)
+			set = func() { dither = mode }
+		case "tint":
+			var mode tintMode
+			err = presetEnum(k, v, &mode)
+			set = func() { tint = mode }
+		case "false-color":
+			text, ok := v.(string)
+			if !ok {
+				return nil, 0, fmt.Errorf("false-color must be a palette name")
+			}
+			set = func() { falseColor = text }
+		case "filters":
+			set, err = presetFilterList(v)
+		default:
+			return nil, 0, fmt.Errorf("unknown setting %v", k)
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+		p.settings = append(p.settings, set)
+	}
+	return p, key, nil
+}
+
+// presetBool returns a setter for a boolean setting.
+func presetBool(name string, v any, setting *bool) (func(), error) {
+	b, ok := v.(bool)
+	if !ok {
+		return nil, fmt.Errorf("%v must be true or false", name)
+	}
+	return func() { *setting = b }, nil
+}
+
+// presetEnum parses a setting with a flag.Value style Set method.
+func presetEnum(name string, v any, setting interface{ Set(string) error }) error {
+	text, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("%v must be a string", name)
+	}
+	return setting.Set(text)
+}
+
+// presetFilterList returns a setter turning on exactly the listed filters.
+func presetFilterList(v any) (func(), error) {
+	items, ok := v.([]any)
+	if !ok {
+		return nil, fmt.Errorf("filters must be a list of names")
+	}
+	on := map[string]bool{}
+	for _, item := range items {
+		name, _ := item.(string)
+		if !slices.ContainsFunc(presetFilters, func(f presetFilter) bool { return f.name == name }) {
+			return nil, fmt.Errorf("unknown filter %q", item)
+		}
+		on[name] = true
+	}
+	return func() {
+		for _, f := range presetFilters {
+			if on[f.name] {
+				f.on()
+			} else {
+				f.off()
+			}
+		}
+	}, nil
+}
+
+// applyPreset applies the preset of number key n and returns a message
+// for the log line.
+func applyPreset(n int) string {
+	p := presets[n-1]
+	if p == nil {
+		return fmt.Sprintf("No preset on key %d", n)
+	}
+	for _, set := range p.settings {
+		set()
+	}
+	activePreset = p.name
+	return fmt.Sprintf("Preset: %v", p.name)
+}
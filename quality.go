@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// qualityWindow is the number of frames whose processing time is
+	// averaged before the quality changes.
+	qualityWindow = 30
+	// qualityHeadroom is the fraction of the frame budget frames must be
+	// expected to take at the next level up before the quality is raised
+	// again. The gap to the budget keeps it from flapping between levels.
+	qualityHeadroom = 0.8
+)
+
+// qualityScales are the fractions of the terminal resolution frames are
+// captured at, from full quality down.
+var qualityScales = []float64{1, 0.75, 0.5, 0.35}
+
+// adaptiveQuality lowers the capture resolution while the viewer cannot
+// convert and draw frames within the frame budget.
+var adaptiveQuality = true
+
+// qualityGovernor tracks how long frames take to process and picks the
+// capture resolution.
+type qualityGovernor struct {
+	level int
+	total time.Duration
+	n     int
+}
+
+var quality qualityGovernor
+
+// feed records the processing time of a frame. It reports whether the
+// quality level changed, which happens at most once per window.
+func (q *qualityGovernor) feed(d time.Duration) bool {
+	q.total += d
+	q.n++
+	if q.n < qualityWindow {
+		return false
+	}
+	avg := q.total / time.Duration(q.n)
+	q.total, q.n = 0, 0
+
+	switch {
+	case avg > frameBudget && q.level < len(qualityScales)-1:
+		q.level++
+	case q.level > 0 && float64(avg)*scaleCost(q.level-1, q.level) < float64(frameBudget)*qualityHeadroom:
+		q.level--
+	default:
+		return false
+	}
+	diag.Info("quality changed", "scale", qualityScales[q.level], "frame time", avg)
+	return true
+}
+
+// scaleCost returns how many times more work a frame takes at level a
+// than at level b, as the work grows with the number of cells.
+func scaleCost(a, b int) float64 {
+	return qualityScales[a] * qualityScales[a] / (qualityScales[b] * qualityScales[b])
+}
+
+// reset goes back to full quality.
+func (q *qualityGovernor) reset() {
+	*q = qualityGovernor{}
+}
+
+// degraded reports whether frames are captured below full resolution.
+func (q *qualityGovernor) degraded() bool {
+	return q.level > 0
+}
+
+// size scales a capture size of cols x rows cells to the quality level.
+func (q *qualityGovernor) size(cols, rows int) (int, int) {
+	scale := qualityScales[q.level]
+	return max(int(float64(cols)*scale), 1), max(int(float64(rows)*scale), 1)
+}
+
+func qualityReadout() string {
+	if !quality.degraded() {
+		return "Quality: full"
+	}
+	return fmt.Sprintf("Quality: %.0f%% resolution", qualityScales[quality.level]*100)
+}
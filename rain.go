@@ -0,0 +1,105 @@
+package main
+
+import (
+	"image/color"
+	"math/rand"
+	"time"
+)
+
+const (
+	// rainFlicker is the chance per step that a cell picks a new glyph.
+	rainFlicker = 0.02
+	// rainMinSpeed and rainMaxSpeed bound how fast drops fall, in rows per
+	// second.
+	rainMinSpeed = 4
+	rainMaxSpeed = 14
+)
+
+// rainGlyphs are half-width katakana, which take one cell, and digits.
+var rainGlyphs = func() []rune {
+	glyphs := []rune("0123456789")
+	for r := rune(0xff66); r <= 0xff9d; r++ {
+		glyphs = append(glyphs, r)
+	}
+	return glyphs
+}()
+
+// rainColumn is one falling drop.
+type rainColumn struct {
+	head   float64
+	speed  float64
+	length int
+}
+
+// matrixRain renders the video as falling columns of random glyphs whose
+// intensity still follows the image.
+type matrixRain struct {
+	width, height int
+	columns       []rainColumn
+	glyphs        []rune
+	last          time.Time
+	rng           *rand.Rand
+}
+
+func newMatrixRain() *matrixRain {
+	return &matrixRain{rng: rand.New(rand.NewSource(time.Now().UnixNano()))}
+}
+
+func (m *matrixRain) newColumn(height int) rainColumn {
+	return rainColumn{
+		head:   -m.rng.Float64() * float64(height),
+		speed:  rainMinSpeed + m.rng.Float64()*(rainMaxSpeed-rainMinSpeed),
+		length: height/3 + m.rng.Intn(height/2+1),
+	}
+}
+
+// advance moves the drops to now, resetting the state when the size changed.
+func (m *matrixRain) advance(width, height int, now time.Time) {
+	if width != m.width || height != m.height {
+		m.width, m.height = width, height
+		m.columns = make([]rainColumn, width)
+		for x := range m.columns {
+			m.columns[x] = m.newColumn(height)
+		}
+		m.glyphs = make([]rune, width*height)
+		for i := range m.glyphs {
+			m.glyphs[i] = rainGlyphs[m.rng.Intn(len(rainGlyphs))]
+		}
+		m.last = now
+	}
+
+	dt := now.Sub(m.last).Seconds()
+	m.last = now
+	for x := range m.columns {
+		col := &m.columns[x]
+		col.head += col.speed * dt
+		if int(col.head)-col.length > height {
+			*col = m.newColumn(height)
+		}
+	}
+	for i := range m.glyphs {
+		if m.rng.Float64() < rainFlicker {
+			m.glyphs[i] = rainGlyphs[m.rng.Intn(len(rainGlyphs))]
+		}
+	}
+}
+
+// cell returns the glyph and color of the rain at x, y over video cell vc.
+func (m *matrixRain) cell(x, y int, vc cell) (rune, color.RGBA) {
+	v := adjustLevels(brightness(vc.color))
+	if x >= m.width || y >= m.height || v < 0.08 {
+		return ' ', color.RGBA{0, 0, 0, 255}
+	}
+
+	// cells in a drop's trail glow brighter, fading away from its head
+	col := m.columns[x]
+	trail := float32(0)
+	if dist := col.head - float64(y); dist >= 0 && dist < float64(col.length) {
+		trail = 1 - float32(dist)/float32(col.length)
+	}
+	intensity := min(v*(0.45+0.55*trail), 1)
+	if trail > 0.95 {
+		return m.glyphs[y*m.width+x], color.RGBA{clampChannel(200 * v), 255, clampChannel(200 * v), 255}
+	}
+	return m.glyphs[y*m.width+x], color.RGBA{0, clampChannel(255 * intensity), clampChannel(70 * intensity), 255}
+}
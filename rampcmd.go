@@ -0,0 +1,203 @@
+//go:build !minimal
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"gocv.io/x/gocv"
+)
+
+func init() {
+	subcommands["ramp"] = subcommand{run: runRamp, summary: "generate a glyph ramp with evenly spaced tones", failure: "Error generating ramp"}
+}
+
+// glyphDensity is the measured ink coverage of a candidate glyph, relative
+// to the densest candidate.
+type glyphDensity struct {
+	r       rune
+	density float64
+}
+
+// builtinDensities returns the densities of the candidates from the glyph
+// matching bitmaps.
+func builtinDensities(candidates []rune) ([]glyphDensity, error) {
+	var densities []glyphDensity
+	for _, r := range candidates {
+		i := slices.IndexFunc(glyphTemplates, func(t glyphTemplate) bool { return t.r == r })
+		if i < 0 {
+			return nil, fmt.Errorf("no built-in bitmap for %q, measure it with --sample", r)
+		}
+		densities = append(densities, glyphDensity{r, float64(glyphTemplates[i].mean)})
+	}
+	return densities, nil
+}
+
+// measureDensities measures the candidates' ink coverage from a picture of
+// them rendered on one line in the target font, cropped to the text. Ink is
+// whatever differs from the most common gray level, so both dark-on-light
+// and light-on-dark samples work.
+func measureDensities(sample string, candidates []rune) ([]glyphDensity, error) {
+	img := gocv.IMRead(sample, gocv.IMReadGrayScale)
+	defer img.Close()
+	if img.Empty() {
+		return nil, fmt.Errorf("could not read image %v", sample)
+	}
+
+	var histogram [256]int
+	for y := 0; y < img.Rows(); y++ {
+		for x := 0; x < img.Cols(); x++ {
+			histogram[img.GetUCharAt(y, x)]++
+		}
+	}
+	background := 0
+	for v, n := range histogram {
+		if n > histogram[background] {
+			background = v
+		}
+	}
+
+	cellWidth := float64(img.Cols()) / float64(len(candidates))
+	if cellWidth < 1 {
+		return nil, fmt.Errorf("sample %v is narrower than the %d candidates", sample, len(candidates))
+	}
+	densities := make([]glyphDensity, len(candidates))
+	for i, r := range candidates {
+		x0, x1 := int(float64(i)*cellWidth), int(float64(i+1)*cellWidth)
+		var ink float64
+		for y := 0; y < img.Rows(); y++ {
+			for x := x0; x < x1; x++ {
+				ink += math.Abs(float64(img.GetUCharAt(y, x)) - float64(background))
+			}
+		}
+		densities[i] = glyphDensity{r, ink / float64((x1-x0)*img.Rows())}
+	}
+	return densities, nil
+}
+
+// selectRamp picks size glyphs, ordered by density, whose normalized
+// densities are closest in the least squares sense to the tonal curve
+// (i/(size-1))^gamma.
+func selectRamp(densities []glyphDensity, size int, gamma float64) ([]glyphDensity, error) {
+	if size < 2 || size > len(densities) {
+		return nil, fmt.Errorf("ramp size must be between 2 and the %d candidates", len(densities))
+	}
+	sorted := slices.Clone(densities)
+	slices.SortStableFunc(sorted, func(a, b glyphDensity) int {
+		switch {
+		case a.density < b.density:
+			return -1
+		case a.density > b.density:
+			return 1
+		}
+		return 0
+	})
+	lo, hi := sorted[0].density, sorted[len(sorted)-1].density
+	if hi == lo {
+		return nil, fmt.Errorf("all candidates have the same density")
+	}
+
+	// cost[k][j] is the best error of a ramp whose k-th glyph is sorted[j],
+	// and from[k][j] the index of its (k-1)-th glyph
+	cost := make([][]float64, size)
+	from := make([][]int, size)
+	for k := range cost {
+		cost[k] = make([]float64, len(sorted))
+		from[k] = make([]int, len(sorted))
+		target := math.Pow(float64(k)/float64(size-1), gamma)
+		for j, g := range sorted {
+			d := (g.density-lo)/(hi-lo) - target
+			cost[k][j] = math.Inf(1)
+			if k == 0 {
+				cost[k][j] = d * d
+				continue
+			}
+			for i := k - 1; i < j; i++ {
+				if c := cost[k-1][i] + d*d; c < cost[k][j] {
+					cost[k][j], from[k][j] = c, i
+				}
+			}
+		}
+	}
+
+	best := size - 1
+	for j := range sorted {
+		if cost[size-1][j] < cost[size-1][best] {
+			best = j
+		}
+	}
+	ramp := make([]glyphDensity, size)
+	for k := size - 1; k >= 0; k-- {
+		ramp[k] = sorted[best]
+		ramp[k].density = (ramp[k].density - lo) / (hi - lo)
+		best = from[k][best]
+	}
+	return ramp, nil
+}
+
+// runRamp implements the ramp subcommand, which generates a glyph ramp with
+// evenly spaced tones from a candidate set and optionally saves it as a
+// preset for the viewer's -ramp flag.
+func runRamp(args []string) error {
+	var all []rune
+	for _, g := range glyphBitmaps {
+		all = append(all, g.r)
+	}
+
+	fs := flag.NewFlagSet("ramp", flag.ExitOnError)
+	candidates := fs.String("candidates", string(all), "glyphs to choose from")
+	sample := fs.String("sample", "", "image of the candidates rendered on one line in the target font (default: built-in bitmaps)")
+	size := fs.Int("size", 10, "number of glyphs in the ramp")
+	gamma := fs.Float64("gamma", 1, "tonal curve: densities follow (i/(size-1))^gamma")
+	name := fs.String("save", "", "save the ramp as a preset with this name")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s ramp [flags]\n", filepath.Base(os.Args[0]))
+		fs.PrintDefaults()
+	}
+	if _, err := parseInterspersed(fs, args); err != nil {
+		return err
+	}
+
+	var seen []rune
+	for _, r := range *candidates {
+		if !slices.Contains(seen, r) {
+			seen = append(seen, r)
+		}
+	}
+
+	var densities []glyphDensity
+	var err error
+	if *sample != "" {
+		densities, err = measureDensities(*sample, seen)
+	} else {
+		densities, err = builtinDensities(seen)
+	}
+	if err != nil {
+		return err
+	}
+
+	ramp, err := selectRamp(densities, *size, *gamma)
+	if err != nil {
+		return err
+	}
+	runes := make([]rune, len(ramp))
+	for i, g := range ramp {
+		runes[i] = g.r
+		fmt.Printf("%q %.3f\n", g.r, g.density)
+	}
+	fmt.Printf("ramp: %q\n", string(runes))
+
+	if *name != "" {
+		path, err := saveRamp(*name, runes)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("saved to %v\n", path)
+	}
+	return nil
+}
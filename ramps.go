@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// rampPath returns the file a named ramp preset is stored in.
+func rampPath(name string) (string, error) {
+	if name == "" || strings.ContainsAny(name, `/\`) {
+		return "", fmt.Errorf("invalid ramp name %q", name)
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "ascii-webcam", "ramps", name+".txt"), nil
+}
+
+// loadRamp reads a named ramp preset, darkest glyph first.
+func loadRamp(name string) ([]rune, error) {
+	path, err := rampPath(name)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	ramp := []rune(strings.TrimRight(string(data), "\r\n"))
+	if len(ramp) < 2 {
+		return nil, fmt.Errorf("ramp %v needs at least two glyphs", path)
+	}
+	return ramp, nil
+}
+
+// saveRamp stores a ramp preset under name and returns its path.
+func saveRamp(name string, ramp []rune) (string, error) {
+	path, err := rampPath(name)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", err
+	}
+	return path, os.WriteFile(path, []byte(string(ramp)+"\n"), 0o644)
+}
@@ -0,0 +1,115 @@
+//go:build !minimal
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"gocv.io/x/gocv"
+)
+
+func init() {
+	subcommands["record"] = subcommand{run: runRecord, summary: "record the camera to .txt, .cast, .html or .json without the viewer", failure: "Error recording"}
+}
+
+// runRecord implements the record subcommand, which converts camera frames
+// straight into an export file until the duration passes or it is
+// interrupted.
+func runRecord(args []string) error {
+	fs := flag.NewFlagSet("record", flag.ExitOnError)
+	out := fs.String("out", "", "output file (.txt, .cast, .html or .json)")
+	duration := fs.Duration("duration", 0, "how long to record (default: until interrupted)")
+	width := fs.Int("width", 80, "output width in characters")
+	height := fs.Int("height", 0, "output height in characters (default: keep aspect ratio)")
+	color := fs.Bool("color", false, "include colors in .cast, .html and .json output")
+	fs.IntVar(&deviceID, "device", 0, "camera device to capture from")
+	fs.BoolVar(&edgesEnabled, "edges", false, "draw strong edges with orientation-matched glyphs")
+	fs.BoolVar(&glyphEnabled, "glyphs", false, "pick glyphs by matching their shape against the image")
+	fs.Var(&dither, "dither", "dithering: none, bayer or fs")
+	fs.BoolVar(&invertEnabled, "invert", false, "invert the glyph ramp for light backgrounds")
+	fs.IntVar(warmupFrames, "warmup", 15, "frames to skip after opening the camera while exposure and white balance settle")
+	fs.StringVar(configPath, "config", "", "config file whose settings for these flags are defaults (default ~/.config/ascii-webcam/config.toml)")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s record [flags] --out out.txt|out.cast|out.html|out.json\n", filepath.Base(os.Args[0]))
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() > 0 || *out == "" {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	cfg, err := loadConfig(*configPath)
+	if err != nil {
+		return err
+	}
+	if err := applyFlagDefaults(cfg, fs, true); err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if *duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *duration)
+		defer cancel()
+	}
+
+	webcam, err := openCamera()
+	if err != nil {
+		return err
+	}
+	defer webcam.Close()
+
+	w, file, err := createFrameWriter(*out, *color)
+	if err != nil {
+		return err
+	}
+	defer discardOutput(file)
+
+	img := gocv.NewMat()
+	defer img.Close()
+
+	small := gocv.NewMat()
+	defer small.Close()
+
+	// cameras ramp exposure and white balance for a moment after opening
+	for skipped := 0; skipped < *warmupFrames && ctx.Err() == nil; {
+		if webcam.Read(&img) && !img.Empty() {
+			skipped++
+		} else {
+			time.Sleep(readRetryDelay)
+		}
+	}
+
+	sx, sy := cellSamples()
+	start := time.Now()
+	for ctx.Err() == nil {
+		if !webcam.Read(&img) || img.Empty() {
+			time.Sleep(readRetryDelay)
+			continue
+		}
+		cols, rows := outputSize(img.Cols(), img.Rows(), *width, *height)
+		smallImage, err := resizeImage(img, &small, cols*sx, rows*sy)
+		if err != nil {
+			return err
+		}
+		f := convertImage(smallImage, cols, rows)
+		f.meta = frameMeta{captured: time.Now(), source: fmt.Sprintf("camera %d", deviceID)}
+		if err := w.writeFrame(f, f.meta.captured.Sub(start)); err != nil {
+			return err
+		}
+	}
+
+	if err := w.close(); err != nil {
+		return err
+	}
+	return finishOutput(file, *out)
+}
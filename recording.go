@@ -0,0 +1,82 @@
+//go:build !minimal
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"time"
+)
+
+// recordingName is the template recording file names are expanded from,
+// with the directives of screenshotName.
+const recordingName = "recording-%Y%m%d-%H%M%S"
+
+// viewerRecording streams the frames as shown to an asciinema recording in
+// a temporary file, which is handed to the capture storage when stopped.
+type viewerRecording struct {
+	file  *os.File
+	w     *castWriter
+	start time.Time
+	name  string
+	// err is the first write error, reported when the recording is saved.
+	err error
+}
+
+// recording is the recording in progress, or nil. Only the UI goroutine
+// uses it.
+var recording *viewerRecording
+
+// startRecording starts recording the frames passed to recordFrame.
+func startRecording() error {
+	file, err := os.CreateTemp("", "ascii-webcam-recording-*.cast")
+	if err != nil {
+		return err
+	}
+	start := time.Now()
+	recording = &viewerRecording{
+		file:  file,
+		w:     newCastWriter(file, false),
+		start: start,
+		name:  path.Join(screenshotDir, expandScreenshotName(recordingName, frameMeta{captured: start})+".cast"),
+	}
+	return nil
+}
+
+// recordFrame adds a frame to the recording in progress as it is shown,
+// after the display modes, tint, false color and CVD simulation.
+func recordFrame(f *frame) {
+	if recording == nil || recording.err != nil {
+		return
+	}
+	recording.err = recording.w.writeShownFrame(showFrame(f), time.Since(recording.start))
+}
+
+// stopRecording ends the recording and returns the job that saves it.
+func stopRecording() exportJob {
+	r := recording
+	recording = nil
+	return exportJob{name: "Recording", run: func() (string, error) {
+		err := r.err
+		if err == nil {
+			err = r.w.close()
+		}
+		if closeErr := r.file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			os.Remove(r.file.Name())
+			return "", err
+		}
+		location, err := captures.saveFile(r.name, r.file.Name())
+		if err != nil {
+			return "", fmt.Errorf("%v, the recording is kept in %v", err, r.file.Name())
+		}
+		return location, nil
+	}}
+}
+
+func isRecording() bool {
+	return recording != nil
+}
@@ -0,0 +1,24 @@
+//go:build minimal
+
+package main
+
+import (
+	"errors"
+)
+
+// startRecording fails in the minimal build, which has no exporters.
+func startRecording() error {
+	return errors.New("recording is not included in the minimal build")
+}
+
+func recordFrame(f *frame) {}
+
+func stopRecording() exportJob {
+	return exportJob{name: "Recording", run: func() (string, error) {
+		return "", errors.New("recording is not included in the minimal build")
+	}}
+}
+
+func isRecording() bool {
+	return false
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/gdamore/tcell"
+)
+
+// resizeDebounce is how long the terminal size must stay unchanged before
+// the capture is resized to it.
+const resizeDebounce = 150 * time.Millisecond
+
+// captureCols and captureRows are the frame size, in cells, the capture
+// goroutine produces. The viewer only updates them once a resize settled,
+// so dragging a window corner does not make every frame a different size.
+var captureCols, captureRows atomic.Int32
+
+// videoSize returns the part of the screen available for video.
+func videoSize(s tcell.Screen) (int, int) {
+	cols, rows := s.Size()
+	return cols, max(rows-logHeight, 0)
+}
+
+// setCaptureSize makes the capture produce frames of cols x rows cells.
+func setCaptureSize(cols, rows int) {
+	captureCols.Store(int32(cols))
+	captureRows.Store(int32(rows))
+}
+
+// captureSize returns the frame size the capture should produce.
+func captureSize() (int, int) {
+	return int(captureCols.Load()), int(captureRows.Load())
+}
+
+// scaleFrame returns f resized to cols x rows cells by repeating or
+// skipping cells, for showing a frame of the wrong size until one of the
+// right size arrives. f is returned as is if it already fits.
+func scaleFrame(f *frame, cols, rows int) *frame {
+	if f == nil || (f.width == cols && f.height == rows) {
+		return f
+	}
+	scaled := newFrame(cols, rows)
+	scaled.meta = f.meta
+	if f.width == 0 || f.height == 0 {
+		return scaled
+	}
+	for y := 0; y < rows; y++ {
+		for x := 0; x < cols; x++ {
+			scaled.set(x, y, f.at(x*f.width/cols, y*f.height/rows))
+		}
+	}
+	return scaled
+}
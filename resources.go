@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell"
+)
+
+// resourcesLayer is the z-order of the resource usage overlay, next to
+// the FPS counter.
+const resourcesLayer = 18
+
+// showResources enables the resource usage overlay.
+var showResources = false
+
+// resourceSampler turns the process CPU time into a usage percentage
+// between samples.
+type resourceSampler struct {
+	lastCPU time.Duration
+	lastAt  time.Time
+}
+
+// sample returns the CPU usage since the previous sample, as a percentage
+// of one core, the resident memory in bytes and the number of goroutines.
+func (r *resourceSampler) sample() (float64, uint64, int) {
+	now := time.Now()
+	cpu, _ := processCPUTime()
+	var percent float64
+	if !r.lastAt.IsZero() {
+		percent = 100 * float64(cpu-r.lastCPU) / float64(now.Sub(r.lastAt))
+	}
+	r.lastCPU, r.lastAt = cpu, now
+	return percent, residentMemory(), runtime.NumGoroutine()
+}
+
+// residentMemory returns the resident set size of the process. Where
+// /proc is missing it falls back to the memory the Go runtime obtained
+// from the system.
+func residentMemory() uint64 {
+	if statm, err := os.ReadFile("/proc/self/statm"); err == nil {
+		if fields := strings.Fields(string(statm)); len(fields) > 1 {
+			if pages, err := strconv.ParseUint(fields[1], 10, 64); err == nil {
+				return pages * uint64(os.Getpagesize())
+			}
+		}
+	}
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	return m.Sys
+}
+
+// drawResources shows the CPU, memory and goroutine counts in the top right
+// corner, below the FPS counter, or removes them when the overlay is off.
+func drawResources(s tcell.Screen, cpu float64, rss uint64, goroutines int) {
+	if !showResources {
+		overlays.remove("resources")
+		return
+	}
+	width, _ := s.Size()
+	text := fmt.Sprintf(" cpu %.0f%% | rss %.1f MiB | %d goroutines ", cpu, float64(rss)/(1<<20), goroutines)
+
+	sf := overlays.surface("resources", resourcesLayer)
+	sf.clear()
+	sf.text(max(width-len(text), 0), 1, text, tcell.StyleDefault.Foreground(palette.message), 0.8)
+}
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"image/color"
+	"io"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// screenshotDir is the directory screenshots are saved in, relative to
+	// the capture storage.
+	screenshotDir = ""
+	// screenshotName is the template screenshot file names are expanded
+	// from, without the extension.
+	screenshotName = "screenshot-%u"
+)
+
+// screenshotFormat writes a screenshot and returns the extension of the
+// file it makes.
+type screenshotFormat func(w io.Writer, sf *shownFrame) (string, error)
+
+// screenshotFormats are the formats screenshots can be saved in, by name.
+// The export formats register themselves from files the minimal build
+// leaves out.
+var screenshotFormats = map[string]screenshotFormat{"text": writeTextScreenshot}
+
+// screenshotFormatName is the name of a registered screenshot format.
+type screenshotFormatName string
+
+func (n screenshotFormatName) String() string {
+	return string(n)
+}
+
+// Set implements flag.Value.
+func (n *screenshotFormatName) Set(name string) error {
+	if _, ok := screenshotFormats[name]; !ok {
+		return fmt.Errorf("unknown screenshot format %q, expected one of %v", name, strings.Join(screenshotFormatNames(), ", "))
+	}
+	*n = screenshotFormatName(name)
+	return nil
+}
+
+// screenshotFormatNames returns the names of the registered formats.
+func screenshotFormatNames() []string {
+	names := make([]string, 0, len(screenshotFormats))
+	for name := range screenshotFormats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// screenshotAs is the format of new screenshots.
+var screenshotAs screenshotFormatName = "text"
+
+var (
+	// exportForeground and exportBackground stand in for the terminal's
+	// default colors in exports that need real colors.
+	exportForeground = color.RGBA{0xcc, 0xcc, 0xcc, 255}
+	exportBackground = color.RGBA{0, 0, 0, 255}
+)
+
+// screenshotNameHelp documents the directives of screenshotName.
+const screenshotNameHelp = "screenshot file name without extension: %Y, %m, %d, %H, %M and %S expand to the capture time, %n to the frame number, %s to the source, %u to a unique id and %% to a percent sign"
+
+// styledCell is a cell with the colors it was shown in. A color with zero
+// alpha is the terminal's default.
+type styledCell struct {
+	r      rune
+	fg, bg color.RGBA
+}
+
+// shownFrame is a frame as the viewer showed it. It is taken on the UI
+// goroutine, so exports can render it later while the modes change.
+type shownFrame struct {
+	width, height int
+	cells         []styledCell
+	meta          frameMeta
+	// settings are those the frame was shown with.
+	settings sourceSettings
+	// colored is set when any cell has a color.
+	colored bool
+}
+
+// showFrame captures how f is shown in the current mode, or returns nil
+// without a frame.
+func showFrame(f *frame) *shownFrame {
+	if f == nil {
+		return nil
+	}
+	sf := &shownFrame{width: f.width, height: f.height, cells: make([]styledCell, len(f.cells)), meta: f.meta, settings: currentSourceSettings()}
+	for i, c := range f.cells {
+		r, fg, bg := displayedCell(c)
+		sf.cells[i] = styledCell{r: r, fg: fg, bg: bg}
+		sf.colored = sf.colored || fg.A > 0 || bg.A > 0
+	}
+	return sf
+}
+
+// newShownFrame wraps a frame that is shown in its own colors if color is
+// set and in the default colors otherwise, as the converter exports it.
+func newShownFrame(f *frame, color bool) *shownFrame {
+	sf := &shownFrame{width: f.width, height: f.height, cells: make([]styledCell, len(f.cells)), meta: f.meta, settings: currentSourceSettings(), colored: color}
+	sf.settings.Color = color
+	for i, c := range f.cells {
+		sf.cells[i].r = c.r
+		// cells read back from a recording have no alpha where no color was set
+		if color && c.color.A > 0 {
+			sf.cells[i].fg = c.color
+			sf.cells[i].fg.A = 255
+		}
+	}
+	return sf
+}
+
+// at returns the cell at x, y.
+func (sf *shownFrame) at(x, y int) styledCell {
+	return sf.cells[y*sf.width+x]
+}
+
+// saveScreenshot writes a screenshot to the capture storage in the
+// -screenshot-format. Text screenshots keep their colors as ANSI escapes.
+func saveScreenshot(sf *shownFrame) (string, error) {
+	if sf == nil {
+		return "", fmt.Errorf("no frame captured yet")
+	}
+
+	var buf bytes.Buffer
+	ext, err := screenshotFormats[string(screenshotAs)](&buf, sf)
+	if err != nil {
+		return "", err
+	}
+	return captures.save(screenshotPath(sf, ext), buf.Bytes())
+}
+
+// writeTextScreenshot writes a screenshot as text, with ANSI colors if it
+// has any.
+func writeTextScreenshot(w io.Writer, sf *shownFrame) (string, error) {
+	if sf.colored {
+		return ".ans", writeANSI(w, sf)
+	}
+	return ".txt", writeShownText(w, sf)
+}
+
+// writeShownText writes the glyphs of a frame, one row per line.
+func writeShownText(w io.Writer, sf *shownFrame) error {
+	bw := bufio.NewWriter(w)
+	for y := 0; y < sf.height; y++ {
+		for x := 0; x < sf.width; x++ {
+			bw.WriteRune(sf.at(x, y).r)
+		}
+		bw.WriteByte('\n')
+	}
+	return bw.Flush()
+}
+
+// writeANSI writes a frame with ANSI truecolor escapes, so printing the
+// file on a terminal reproduces it. Colors are only set where they change
+// and reset at the end of every line.
+func writeANSI(w io.Writer, sf *shownFrame) error {
+	bw := bufio.NewWriter(w)
+	for y := 0; y < sf.height; y++ {
+		var fg, bg color.RGBA
+		for x := 0; x < sf.width; x++ {
+			c := sf.at(x, y)
+			if c.fg != fg || c.bg != bg {
+				bw.WriteString(ansiStyle(c.fg, c.bg))
+				fg, bg = c.fg, c.bg
+			}
+			bw.WriteRune(c.r)
+		}
+		bw.WriteString("\x1b[0m\n")
+	}
+	return bw.Flush()
+}
+
+// ansiStyle returns the escape sequence that selects the given colors,
+// resetting any that are the terminal's default.
+func ansiStyle(fg, bg color.RGBA) string {
+	s := "\x1b[0m"
+	if fg.A > 0 {
+		s += fmt.Sprintf("\x1b[38;2;%d;%d;%dm", fg.R, fg.G, fg.B)
+	}
+	if bg.A > 0 {
+		s += fmt.Sprintf("\x1b[48;2;%d;%d;%dm", bg.R, bg.G, bg.B)
+	}
+	return s
+}
+
+// screenshotPath returns the storage name of a screenshot of sf with the
+// given extension.
+func screenshotPath(sf *shownFrame, ext string) string {
+	return path.Join(screenshotDir, expandScreenshotName(screenshotName, sf.meta)+ext)
+}
+
+// expandScreenshotName replaces the strftime-style directives of template
+// with the values for a frame. Unknown directives are kept as written.
+func expandScreenshotName(template string, meta frameMeta) string {
+	var b strings.Builder
+	t := meta.captured
+	for i := 0; i < len(template); i++ {
+		if template[i] != '%' || i == len(template)-1 {
+			b.WriteByte(template[i])
+			continue
+		}
+		i++
+		switch template[i] {
+		case 'Y':
+			fmt.Fprintf(&b, "%04d", t.Year())
+		case 'm':
+			fmt.Fprintf(&b, "%02d", int(t.Month()))
+		case 'd':
+			fmt.Fprintf(&b, "%02d", t.Day())
+		case 'H':
+			fmt.Fprintf(&b, "%02d", t.Hour())
+		case 'M':
+			fmt.Fprintf(&b, "%02d", t.Minute())
+		case 'S':
+			fmt.Fprintf(&b, "%02d", t.Second())
+		case 'n':
+			fmt.Fprintf(&b, "%06d", meta.number)
+		case 's':
+			b.WriteString(fileNamePart(meta.source))
+		case 'u':
+			b.WriteString(uuid.New().String())
+		case '%':
+			b.WriteByte('%')
+		default:
+			b.WriteByte('%')
+			b.WriteByte(template[i])
+		}
+	}
+	return b.String()
+}
+
+// fileNamePart turns a source description such as "camera 0+1 (anaglyph)"
+// into something safe to use in a file name.
+func fileNamePart(s string) string {
+	s = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.', r == '+':
+			return r
+		}
+		return '-'
+	}, s)
+	s = strings.Trim(s, "-")
+	if s == "" {
+		return "unknown"
+	}
+	return s
+}
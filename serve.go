@@ -0,0 +1,95 @@
+//go:build !minimal
+
+package main
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+func init() {
+	subcommands["serve"] = subcommand{run: runServe, summary: "view the camera and serve the current frame over HTTP", failure: "Error serving"}
+}
+
+// runServe implements the serve subcommand, the viewer with the current
+// frame served over HTTP on :8080 unless -serve says otherwise.
+func runServe(args []string) error {
+	return runView(append([]string{"-serve", ":8080"}, args...))
+}
+
+// startServer serves the frame API on addr in the background.
+func startServer(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/frame", simulateLinkHandler(handleFrame))
+	mux.HandleFunc("/metrics", handleMetrics)
+	go http.Serve(listener, mux)
+	return nil
+}
+
+// handleMetrics returns the frame metrics for Prometheus.
+func handleMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	writeMetrics(w)
+}
+
+// handleFrame returns the current frame as text, ANSI or PNG depending on
+// the format query parameter.
+func handleFrame(w http.ResponseWriter, r *http.Request) {
+	f := latestFrame.Load()
+	if f == nil {
+		http.Error(w, "no frame captured yet", http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Header().Set("X-Frame-Captured", f.meta.captured.Format(time.RFC3339Nano))
+	w.Header().Set("X-Frame-Source", f.meta.source)
+	w.Header().Set("X-Frame-Scene", strconv.Itoa(f.meta.scene))
+
+	switch format := r.URL.Query().Get("format"); format {
+	case "", "text", "txt":
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		writeText(w, f)
+	case "ansi":
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Write([]byte(ansiFrame(f, true) + "\r\n"))
+	case "png":
+		data, err := rasterizeFrame(newShownFrame(f, true))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(data)
+	default:
+		http.Error(w, "unknown format "+format, http.StatusBadRequest)
+	}
+}
+
+// throttledResponseWriter paces writes to the simulated bandwidth.
+type throttledResponseWriter struct {
+	http.ResponseWriter
+}
+
+func (w throttledResponseWriter) Write(p []byte) (int, error) {
+	time.Sleep(transferTime(len(p)))
+	return w.ResponseWriter.Write(p)
+}
+
+// simulateLinkHandler wraps an HTTP handler with the simulated latency and
+// bandwidth.
+func simulateLinkHandler(h http.HandlerFunc) http.HandlerFunc {
+	if *simLatency <= 0 && *simBandwidth <= 0 {
+		return h
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(*simLatency)
+		h(throttledResponseWriter{w}, r)
+	}
+}
@@ -0,0 +1,12 @@
+//go:build minimal
+
+package main
+
+import (
+	"errors"
+)
+
+// startServer fails in the minimal build, which has no HTTP server.
+func startServer(addr string) error {
+	return errors.New("the frame server is not included in the minimal build")
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"image"
+
+	"gocv.io/x/gocv"
+)
+
+const (
+	sharpenStep = 0.25
+	maxSharpen  = 3
+	// sharpenSigma is the blur radius of the unsharp mask, in pixels of the
+	// resized image.
+	sharpenSigma = 1
+)
+
+// sharpenAmount is the strength of the sharpen filter, or 0 when it is off.
+var sharpenAmount float64 = 0
+
+// sharpenFilter is an unsharp mask: it adds the difference between the
+// image and a blurred copy back to the image, so details lost in
+// downscaling stand out.
+type sharpenFilter struct {
+	amount float64
+}
+
+func (sharpenFilter) name() string { return "sharpen" }
+
+func (f sharpenFilter) apply(img *gocv.Mat) {
+	blurred := gocv.NewMat()
+	defer blurred.Close()
+	gocv.GaussianBlur(*img, &blurred, image.Point{}, sharpenSigma, sharpenSigma, gocv.BorderDefault)
+	gocv.AddWeighted(*img, 1+f.amount, blurred, -f.amount, 0, img)
+}
+
+// setSharpen sets the sharpen strength, turning the filter off at 0.
+func setSharpen(amount float64) {
+	sharpenAmount = min(max(amount, 0), maxSharpen)
+	if sharpenAmount == 0 {
+		removeImageFilter(sharpenFilter{}.name())
+		return
+	}
+	setImageFilter(sharpenFilter{amount: sharpenAmount})
+}
+
+// changeSharpen moves the sharpen strength by steps.
+func changeSharpen(steps int) {
+	setSharpen(sharpenAmount + float64(steps)*sharpenStep)
+}
+
+func sharpenReadout() string {
+	if sharpenAmount == 0 {
+		return "Sharpen: off"
+	}
+	return fmt.Sprintf("Sharpen: %.2f", sharpenAmount)
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/gdamore/tcell"
+)
+
+// handleSignals makes SIGINT, SIGTERM and SIGHUP quit the viewer the same
+// way the quit key does, so the camera is closed and the terminal leaves
+// raw mode and the alternate screen. A second signal restores the terminal
+// and exits right away, in case the viewer is stuck. The returned function
+// stops the handling.
+func handleSignals(s tcell.Screen) func() {
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+	stopped := make(chan struct{})
+	go func() {
+		select {
+		case sig := <-signals:
+			diag.Info("quitting on signal", "signal", sig)
+			s.PostEvent(tcell.NewEventInterrupt(sig))
+		case <-stopped:
+			return
+		}
+		select {
+		case <-signals:
+			resetTerminal(s)
+			os.Exit(1)
+		case <-stopped:
+		}
+	}()
+	return func() {
+		signal.Stop(signals)
+		close(stopped)
+	}
+}
+
+// resetTerminal undoes the terminal setup of the viewer.
+func resetTerminal(s tcell.Screen) {
+	disableFocusReporting()
+	popTitle()
+	s.Fini()
+}
+
+// restoreTerminal is deferred by the viewer's goroutines so that a panic
+// resets the terminal before the program crashes with its trace.
+func restoreTerminal(s tcell.Screen) {
+	if r := recover(); r != nil {
+		resetTerminal(s)
+		panic(r)
+	}
+}
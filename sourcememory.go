@@ -0,0 +1,178 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// rememberSettings makes the viewer restore the settings last used with a
+// source when it is opened again.
+var rememberSettings = true
+
+// givenFlags holds the flags set on the command line, whose settings are
+// not overridden by remembered ones.
+var givenFlags = map[string]bool{}
+
+// sourceSettings are the settings remembered for a camera or file.
+type sourceSettings struct {
+	Zoom    float64  `json:"zoom"`
+	X       float64  `json:"x"`
+	Y       float64  `json:"y"`
+	Charset string   `json:"charset"`
+	Color   bool     `json:"color"`
+	Pixel   bool     `json:"pixel"`
+	Edges   bool     `json:"edges"`
+	Glyphs  bool     `json:"glyphs"`
+	Invert  bool     `json:"invert"`
+	Dither  string   `json:"dither"`
+	Tint    string   `json:"tint"`
+	Filters []string `json:"filters"`
+}
+
+// sourceMemoryPath returns the file the settings of every source are kept
+// in.
+func sourceMemoryPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "ascii-webcam", "sources.json"), nil
+}
+
+// loadSourceMemory reads the remembered settings by source name. A missing
+// file yields no settings.
+func loadSourceMemory() (map[string]sourceSettings, error) {
+	memory := map[string]sourceSettings{}
+	path, err := sourceMemoryPath()
+	if err != nil {
+		return memory, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return memory, nil
+	}
+	if err != nil {
+		return memory, err
+	}
+	return memory, json.Unmarshal(data, &memory)
+}
+
+// saveSourceMemory writes the remembered settings.
+func saveSourceMemory(memory map[string]sourceSettings) error {
+	path, err := sourceMemoryPath()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(memory, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// currentSourceSettings captures the current settings.
+func currentSourceSettings() sourceSettings {
+	st := sourceSettings{
+		Charset: string(runes),
+		Color:   colorEnabled,
+		Pixel:   pixelEnabled,
+		Edges:   edgesEnabled,
+		Glyphs:  glyphEnabled,
+		Invert:  invertEnabled,
+		Dither:  dither.String(),
+		Tint:    tint.String(),
+	}
+	st.Zoom, st.X, st.Y = view.state()
+	for _, f := range presetFilters {
+		if f.active() {
+			st.Filters = append(st.Filters, f.name)
+		}
+	}
+	return st
+}
+
+// sourceMemory tracks the source being viewed and the settings remembered
+// for every source.
+type sourceMemory struct {
+	settings map[string]sourceSettings
+	source   string
+}
+
+// newSourceMemory loads the remembered settings, starting empty if the
+// state file cannot be read.
+func newSourceMemory() *sourceMemory {
+	settings, err := loadSourceMemory()
+	if err != nil {
+		diag.Warn("reading remembered settings", "err", err)
+	}
+	return &sourceMemory{settings: settings}
+}
+
+// switchTo remembers the settings of the previous source and restores those
+// last used with source.
+func (m *sourceMemory) switchTo(source string) {
+	if m.source != "" {
+		m.settings[m.source] = currentSourceSettings()
+		m.save()
+	}
+	m.source = source
+	if st, ok := m.settings[source]; ok {
+		st.apply()
+	}
+}
+
+// remember records the settings of the current source in the state file.
+func (m *sourceMemory) remember() {
+	if m.source == "" {
+		return
+	}
+	m.settings[m.source] = currentSourceSettings()
+	m.save()
+}
+
+func (m *sourceMemory) save() {
+	if err := saveSourceMemory(m.settings); err != nil {
+		diag.Warn("saving remembered settings", "err", err)
+	}
+}
+
+// apply restores the settings, except those given as flags.
+func (st sourceSettings) apply() {
+	if st.Zoom > 0 {
+		view.restore(st.Zoom, st.X, st.Y)
+	}
+	if len([]rune(st.Charset)) >= 2 && !givenFlags["charset"] && !givenFlags["ramp"] {
+		runes = []rune(st.Charset)
+	}
+	colorEnabled, pixelEnabled, edgesEnabled = st.Color, st.Pixel, st.Edges
+	if glyphEnabled != st.Glyphs {
+		glyphEnabled = st.Glyphs
+		updateSamples()
+	}
+	if !givenFlags["invert"] {
+		invertEnabled = st.Invert
+	}
+	if !givenFlags["dither"] {
+		dither.Set(st.Dither)
+	}
+	if !givenFlags["tint"] {
+		tint.Set(st.Tint)
+	}
+	on := map[string]bool{}
+	for _, name := range st.Filters {
+		on[name] = true
+	}
+	for _, f := range presetFilters {
+		if on[f.name] {
+			f.on()
+		} else {
+			f.off()
+		}
+	}
+}
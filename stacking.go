@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+
+	"gocv.io/x/gocv"
+)
+
+// stackSizes are the window sizes the stacking key cycles through.
+var stackSizes = []int{0, 4, 8, 16, 32, 64}
+
+// stackFrames is the number of frames averaged by the stacking filter, or
+// 0 when it is off.
+var stackFrames int
+
+// stackHistory holds the frames in the window, oldest first, and stackSum
+// their per-pixel sum. They are only touched with imageFilters.mu held.
+var (
+	stackHistory []gocv.Mat
+	stackSum     *gocv.Mat
+)
+
+// stackFilter averages the last frames of a window, like astrophotography
+// stacking. Noise falls with the square root of the window size, which
+// turns a static scene into a clean portrait, while anything that moves
+// blurs.
+type stackFilter struct {
+	frames int
+}
+
+func (stackFilter) name() string { return "stacking" }
+
+func (f stackFilter) apply(img *gocv.Mat) {
+	if stackSum != nil && (stackSum.Rows() != img.Rows() || stackSum.Cols() != img.Cols() || stackSum.Channels() != img.Channels()) {
+		closeStack()
+	}
+
+	frame := gocv.NewMat()
+	img.ConvertTo(&frame, gocv.MatTypeCV32F)
+	if stackSum == nil {
+		m := frame.Clone()
+		stackSum = &m
+	} else {
+		gocv.Add(*stackSum, frame, stackSum)
+	}
+	stackHistory = append(stackHistory, frame)
+	for len(stackHistory) > f.frames {
+		gocv.Subtract(*stackSum, stackHistory[0], stackSum)
+		stackHistory[0].Close()
+		stackHistory = stackHistory[1:]
+	}
+
+	stackSum.ConvertToWithParams(img, img.Type(), 1/float32(len(stackHistory)), 0)
+}
+
+// closeStack drops the stacked frames.
+func closeStack() {
+	for _, m := range stackHistory {
+		m.Close()
+	}
+	stackHistory = nil
+	if stackSum != nil {
+		stackSum.Close()
+		stackSum = nil
+	}
+}
+
+// setStack sets the stacking window, turning stacking off at 0.
+func setStack(frames int) {
+	stackFrames = max(frames, 0)
+	if stackFrames == 0 {
+		removeImageFilter(stackFilter{}.name())
+		imageFilters.mu.Lock()
+		closeStack()
+		imageFilters.mu.Unlock()
+		return
+	}
+	setImageFilter(stackFilter{frames: stackFrames})
+}
+
+// nextStack switches to the next larger stacking window, wrapping around
+// to off.
+func nextStack() {
+	for _, n := range stackSizes {
+		if n > stackFrames {
+			setStack(n)
+			return
+		}
+	}
+	setStack(0)
+}
+
+func stackReadout() string {
+	if stackFrames == 0 {
+		return "Stacking: off"
+	}
+	return fmt.Sprintf("Stacking: %d frames", stackFrames)
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gdamore/tcell"
+)
+
+// statusSource is the source of the latest frame, shown in the status bar.
+var statusSource = ""
+
+// renderModeName describes how cells are drawn.
+func renderModeName() string {
+	mode := "ascii"
+	switch {
+	case overlays.rain != nil:
+		mode = "rain"
+	case pixelEnabled:
+		mode = "pixel"
+	case glyphEnabled:
+		mode = "glyphs"
+	}
+	if edgesEnabled {
+		mode += "+edges"
+	}
+	return mode
+}
+
+// statusField is an item of the status bar. Clicking it calls click,
+// which returns a message for the log line.
+type statusField struct {
+	text  string
+	click func() string
+}
+
+// statusFields lists the current settings for the status bar.
+func statusFields() []statusField {
+	colorName := "mono"
+	if colorEnabled || pixelEnabled {
+		colorName = colors.String()
+	}
+	levels := fmt.Sprintf("b%+.2f c%.1f", brightnessOffset, contrastGain)
+	if autoExposure {
+		levels += " auto"
+	}
+
+	var fields []statusField
+	if statusSource != "" {
+		fields = append(fields, statusField{text: statusSource})
+	}
+	if activePreset != "" {
+		fields = append(fields, statusField{text: "preset " + activePreset})
+	}
+	if quality.degraded() {
+		fields = append(fields, statusField{text: fmt.Sprintf("%.0f%% res", qualityScales[quality.level]*100)})
+	}
+	return append(fields,
+		statusField{renderModeName(), func() string {
+			pixelEnabled = !pixelEnabled
+			return "Pixel Mode Toggle"
+		}},
+		statusField{fmt.Sprintf("ramp %q", strings.TrimLeft(string(runes), " ")), func() string {
+			nextRamp(1)
+			return rampReadout()
+		}},
+		statusField{colorName, func() string {
+			colorEnabled = !colorEnabled
+			return "Color Toggle"
+		}},
+		statusField{levels, func() string {
+			autoExposure = !autoExposure
+			return exposureReadout()
+		}},
+	)
+}
+
+// statusText joins the status fields into the text of the bar.
+func statusText() string {
+	var texts []string
+	for _, f := range statusFields() {
+		texts = append(texts, f.text)
+	}
+	return " " + strings.Join(texts, statusSeparator) + " "
+}
+
+// statusSeparator goes between status fields.
+const statusSeparator = " | "
+
+// clickStatusBar runs the click action of the status field at column x.
+// It returns false when there is no clickable field there.
+func clickStatusBar(x int) (string, bool) {
+	// fields start after the leading space
+	pos := 1
+	for _, f := range statusFields() {
+		n := len([]rune(f.text))
+		if x >= pos && x < pos+n {
+			if f.click == nil {
+				return "", false
+			}
+			return f.click(), true
+		}
+		pos += n + len(statusSeparator)
+	}
+	return "", false
+}
+
+// drawStatusBar draws the status bar above the log line, filling the
+// whole row so it reads as one bar.
+func drawStatusBar(s tcell.Screen) {
+	width, height := s.Size()
+	bar := overlays.surface("status", logLayer)
+	bar.clear()
+
+	style := tcell.StyleDefault.Reverse(true)
+	y := height - logHeight
+	text := []rune(statusText())
+	for x := 0; x < width; x++ {
+		r := ' '
+		if x < len(text) {
+			r = text[x]
+		}
+		bar.set(x, y, overlayCell{r: r, style: style, alpha: 1})
+	}
+}
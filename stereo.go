@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"image"
+
+	"gocv.io/x/gocv"
+)
+
+// stereoMode selects how frames from two cameras are combined.
+type stereoMode int
+
+const (
+	stereoAnaglyph stereoMode = iota
+	stereoSideBySide
+)
+
+func parseStereoMode(name string) (stereoMode, error) {
+	switch name {
+	case "anaglyph":
+		return stereoAnaglyph, nil
+	case "side-by-side", "sbs":
+		return stereoSideBySide, nil
+	}
+	return 0, fmt.Errorf("unknown stereo mode %q", name)
+}
+
+// readStereo grabs from both cameras before retrieving either frame, so the
+// two images are captured as close together in time as the drivers allow.
+func readStereo(left, right *gocv.VideoCapture, leftImg, rightImg *gocv.Mat) bool {
+	left.Grab(0)
+	right.Grab(0)
+	return left.Retrieve(leftImg) && right.Retrieve(rightImg)
+}
+
+// combineStereo merges a left and right camera image into dst. Anaglyph
+// takes the red channel from the left eye and green/blue from the right,
+// for viewing with red/cyan glasses.
+func combineStereo(left, right gocv.Mat, dst *gocv.Mat, mode stereoMode) {
+	if left.Empty() || right.Empty() {
+		return
+	}
+
+	resized := gocv.NewMat()
+	defer resized.Close()
+	gocv.Resize(right, &resized, image.Point{X: left.Cols(), Y: left.Rows()}, 0, 0, gocv.InterpolationLinear)
+
+	switch mode {
+	case stereoSideBySide:
+		gocv.Hconcat(left, resized, dst)
+	case stereoAnaglyph:
+		leftChannels := gocv.Split(left)
+		rightChannels := gocv.Split(resized)
+		defer func() {
+			for _, m := range append(leftChannels, rightChannels...) {
+				m.Close()
+			}
+		}()
+
+		// Mats are BGR
+		gocv.Merge([]gocv.Mat{rightChannels[0], rightChannels[1], leftChannels[2]}, dst)
+	}
+}
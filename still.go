@@ -0,0 +1,86 @@
+package main
+
+import (
+	"image"
+	"math"
+
+	"github.com/remzisenel/ascii-webcam/stream"
+)
+
+// stillSampleStep is the spacing in pixels of the grid sampled to compare
+// frames, which keeps the comparison far cheaper than a conversion.
+const stillSampleStep = 4
+
+// stillThreshold is the mean brightness difference, in levels of 255, a
+// frame must differ by from the last drawn one to be converted. 0 draws
+// every frame.
+var stillThreshold = 2.0
+
+// stillDetector skips frames of a static scene. It compares frames to the
+// last one drawn rather than the previous one, so slow changes such as
+// fading daylight still add up to a redraw.
+type stillDetector struct {
+	samples []uint8
+	scratch []uint8
+	width   int
+	height  int
+}
+
+// changed reports whether img differs enough from the last frame drawn to
+// be converted, remembering it as drawn if so.
+func (d *stillDetector) changed(img image.Image) bool {
+	if stillThreshold <= 0 || animating() {
+		d.reset()
+		return true
+	}
+	bounds := img.Bounds()
+	samples := sampleLuma(img, d.scratch[:0])
+	d.scratch = samples
+	if bounds.Dx() != d.width || bounds.Dy() != d.height || len(samples) != len(d.samples) || len(samples) == 0 {
+		d.samples, d.scratch = samples, d.samples
+		d.width, d.height = bounds.Dx(), bounds.Dy()
+		return true
+	}
+
+	var diff int
+	for i, v := range samples {
+		diff += int(math.Abs(float64(int(v) - int(d.samples[i]))))
+	}
+	if float64(diff)/float64(len(samples)) < stillThreshold {
+		return false
+	}
+	d.samples, d.scratch = samples, d.samples
+	return true
+}
+
+// reset makes the next frame count as changed, for when the settings
+// changed and the scene must be converted again.
+func (d *stillDetector) reset() {
+	d.width, d.height = 0, 0
+}
+
+// sampleLuma appends the luma of a grid of pixels of img to buf.
+func sampleLuma(img image.Image, buf []uint8) []uint8 {
+	bounds := img.Bounds()
+	pixels := stream.NewPixels(img)
+	for y := 0; y < bounds.Dy(); y += stillSampleStep {
+		for x := 0; x < bounds.Dx(); x += stillSampleStep {
+			buf = append(buf, stream.Luma(pixels.At(x, y)))
+		}
+	}
+	return buf
+}
+
+// animating reports whether the picture changes by itself, so frames of a
+// static scene must still be drawn.
+func animating() bool {
+	if overlays.rain != nil || !hueCycleStart.IsZero() {
+		return true
+	}
+	for _, f := range filters {
+		if f.name() == (noiseFilter{}).name() {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// captureStorage is where screenshots and other captures are written.
+type captureStorage interface {
+	// save stores data under name, a slash-separated path, and returns a
+	// description of where it went.
+	save(name string, data []byte) (string, error)
+	// saveFile moves the file at filename into storage under name, for
+	// captures too large to hold in memory.
+	saveFile(name, filename string) (string, error)
+}
+
+// localStorage writes captures to a directory on disk.
+type localStorage struct {
+	dir string
+}
+
+func (l localStorage) save(name string, data []byte) (string, error) {
+	filename := filepath.Join(l.dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(filename), 0o755); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(filename, data, 0o644); err != nil {
+		return "", err
+	}
+	return filename, nil
+}
+
+func (l localStorage) saveFile(name, filename string) (string, error) {
+	target := filepath.Join(l.dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return "", err
+	}
+	if err := os.Rename(filename, target); err == nil {
+		return target, nil
+	}
+
+	// renaming fails across file systems, so fall back to copying
+	src, err := os.Open(filename)
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+	dst, err := os.Create(target)
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return "", err
+	}
+	if err := dst.Close(); err != nil {
+		return "", err
+	}
+	return target, os.Remove(filename)
+}
+
+// storageConfig selects and configures a capture storage backend.
+type storageConfig struct {
+	kind     string
+	dir      string
+	url      string
+	bucket   string
+	region   string
+	user     string
+	password string
+}
+
+// remoteBackends constructs the storage backends that upload captures
+// elsewhere, by name. They register themselves from storage_remote.go,
+// which the minimal build leaves out.
+var remoteBackends = map[string]func(cfg storageConfig) (captureStorage, error){}
+
+// newStorage creates the backend described by cfg. Credentials for remote
+// backends are taken from the environment.
+func newStorage(cfg storageConfig) (captureStorage, error) {
+	if cfg.kind == "" || cfg.kind == "local" {
+		return localStorage{dir: cfg.dir}, nil
+	}
+	if newBackend, ok := remoteBackends[cfg.kind]; ok {
+		return newBackend(cfg)
+	}
+	return nil, fmt.Errorf("unknown storage backend %q", cfg.kind)
+}
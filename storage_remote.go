@@ -0,0 +1,255 @@
+//go:build !minimal
+
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+func init() {
+	client := &http.Client{Timeout: 30 * time.Second}
+	remoteBackends["webdav"] = func(cfg storageConfig) (captureStorage, error) {
+		if cfg.url == "" {
+			return nil, fmt.Errorf("webdav storage needs a URL")
+		}
+		return webdavStorage{
+			baseURL:  cfg.url,
+			user:     cfg.user,
+			password: cfg.password,
+			client:   client,
+		}, nil
+	}
+	remoteBackends["s3"] = func(cfg storageConfig) (captureStorage, error) {
+		if cfg.url == "" || cfg.bucket == "" {
+			return nil, fmt.Errorf("s3 storage needs an endpoint URL and a bucket")
+		}
+		return s3Storage{
+			endpoint:  cfg.url,
+			bucket:    cfg.bucket,
+			region:    cfg.region,
+			accessKey: os.Getenv("AWS_ACCESS_KEY_ID"),
+			secretKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+			client:    client,
+		}, nil
+	}
+}
+
+// webdavStorage uploads captures to a WebDAV collection with HTTP PUT.
+type webdavStorage struct {
+	baseURL  string
+	user     string
+	password string
+	client   *http.Client
+}
+
+func (w webdavStorage) save(name string, data []byte) (string, error) {
+	return w.put(name, bytes.NewReader(data))
+}
+
+func (w webdavStorage) saveFile(name, filename string) (string, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	target, err := w.put(name, file)
+	if err != nil {
+		return "", err
+	}
+	return target, os.Remove(filename)
+}
+
+// put uploads body under name, creating the collections it goes in.
+func (w webdavStorage) put(name string, body io.Reader) (string, error) {
+	segments := strings.Split(name, "/")
+	target := strings.TrimSuffix(w.baseURL, "/")
+	for i, segment := range segments {
+		target += "/" + url.PathEscape(segment)
+		if i < len(segments)-1 {
+			if err := w.makeCollection(target); err != nil {
+				return "", err
+			}
+		}
+	}
+	req, err := w.request(http.MethodPut, target, body)
+	if err != nil {
+		return "", err
+	}
+	if err := setFileLength(req, body); err != nil {
+		return "", err
+	}
+	return target, doUpload(w.client, req)
+}
+
+// makeCollection creates the collection at target unless it exists, since
+// a PUT into a missing collection fails.
+func (w webdavStorage) makeCollection(target string) error {
+	req, err := w.request("MKCOL", target, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusMethodNotAllowed {
+		return fmt.Errorf("creating collection failed: %v", resp.Status)
+	}
+	return nil
+}
+
+func (w webdavStorage) request(method, target string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequest(method, target, body)
+	if err != nil {
+		return nil, err
+	}
+	if w.user != "" {
+		req.SetBasicAuth(w.user, w.password)
+	}
+	return req, nil
+}
+
+// s3Storage uploads captures to an S3-compatible bucket using path-style
+// URLs and AWS signature version 4.
+type s3Storage struct {
+	endpoint  string
+	bucket    string
+	region    string
+	accessKey string
+	secretKey string
+	client    *http.Client
+}
+
+func (s s3Storage) save(name string, data []byte) (string, error) {
+	payloadHash := sha256.Sum256(data)
+	return s.put(name, bytes.NewReader(data), hex.EncodeToString(payloadHash[:]))
+}
+
+func (s s3Storage) saveFile(name, filename string) (string, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	// the signature covers the payload hash, so the file is read twice
+	// rather than held in memory
+	h := sha256.New()
+	if _, err := io.Copy(h, file); err != nil {
+		return "", err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	location, err := s.put(name, file, hex.EncodeToString(h.Sum(nil)))
+	if err != nil {
+		return "", err
+	}
+	return location, os.Remove(filename)
+}
+
+// put uploads body, whose SHA-256 is hash, under name.
+func (s s3Storage) put(name string, body io.Reader, hash string) (string, error) {
+	endpoint, err := url.Parse(s.endpoint)
+	if err != nil {
+		return "", err
+	}
+	endpoint.Path = path.Join("/", endpoint.Path, s.bucket, name)
+
+	req, err := http.NewRequest(http.MethodPut, endpoint.String(), body)
+	if err != nil {
+		return "", err
+	}
+	if err := setFileLength(req, body); err != nil {
+		return "", err
+	}
+	s.sign(req, hash, time.Now().UTC())
+	return fmt.Sprintf("s3://%v/%v", s.bucket, name), doUpload(s.client, req)
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// sign adds AWS signature version 4 headers to an upload request whose
+// payload has the given hex SHA-256.
+func (s s3Storage) sign(req *http.Request, hash string, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+
+	req.Header.Set("x-amz-content-sha256", hash)
+	req.Header.Set("x-amz-date", amzDate)
+
+	const signedHeaders = "host;x-amz-content-sha256;x-amz-date"
+	canonical := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		"host:" + req.URL.Host,
+		"x-amz-content-sha256:" + hash,
+		"x-amz-date:" + amzDate,
+		"",
+		signedHeaders,
+		hash,
+	}, "\n")
+	canonicalHash := sha256.Sum256([]byte(canonical))
+
+	scope := date + "/" + s.region + "/s3/aws4_request"
+	toSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		hex.EncodeToString(canonicalHash[:]),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.secretKey), date)
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, toSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%v/%v, SignedHeaders=%v, Signature=%v",
+		s.accessKey, scope, signedHeaders, signature))
+}
+
+// setFileLength sets the length of a request whose body is a file, which
+// the request only learns by itself for in-memory bodies.
+func setFileLength(req *http.Request, body io.Reader) error {
+	file, ok := body.(*os.File)
+	if !ok {
+		return nil
+	}
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	req.ContentLength = info.Size()
+	return nil
+}
+
+// doUpload sends an upload request and turns non-2xx responses into errors.
+func doUpload(client *http.Client, req *http.Request) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("upload failed: %v", resp.Status)
+	}
+	return nil
+}
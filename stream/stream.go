@@ -0,0 +1,276 @@
+// Package stream exposes webcam frames converted to character cells for
+// programs that draw them in their own terminal UI, such as dashboards built
+// with bubbletea or tview:
+//
+//	cam, err := stream.Open(stream.Options{Width: 80, Height: 24})
+//	if err != nil {
+//		return err
+//	}
+//	defer cam.Close()
+//	for frame := range cam.Frames(ctx) {
+//		// draw frame.Cells
+//	}
+//
+// It provides the plain brightness-ramp conversion of the ascii-webcam
+// viewer without its interactive filters and adjustments.
+package stream
+
+import (
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"sync"
+	"time"
+
+	"gocv.io/x/gocv"
+)
+
+const (
+	// maxReadFailures is the number of consecutive failed reads after which
+	// Frames gives up.
+	maxReadFailures = 30
+	// readRetryDelay is the wait after the first failed read, doubled after
+	// each further one up to maxReadRetryDelay.
+	readRetryDelay    = 50 * time.Millisecond
+	maxReadRetryDelay = time.Second
+)
+
+// ErrReadFailed is returned by Err when Frames stopped because the device
+// kept failing to deliver images.
+var ErrReadFailed = errors.New("stream: camera stopped delivering frames")
+
+// DefaultRamp is the glyph ramp used when Options.Ramp is empty, darkest
+// glyph first.
+var DefaultRamp = []rune{' ', ' ', ' ', ' ', '.', ',', ':', ';', '+', '*', '?', '%', 'S', '#', '@'}
+
+// Cell is one character cell of a frame, with the average color of the
+// source pixels it covers.
+type Cell struct {
+	Rune  rune
+	Color color.RGBA
+}
+
+// Frame is a converted grid of cells, stored row by row.
+type Frame struct {
+	Width, Height int
+	Cells         []Cell
+	// Time is when the source image was captured.
+	Time time.Time
+}
+
+// At returns the cell at x, y.
+func (f *Frame) At(x, y int) Cell {
+	return f.Cells[y*f.Width+x]
+}
+
+// Options configures a Stream.
+type Options struct {
+	// Device is the index of the capture device.
+	Device int
+	// Width and Height are the size of the frames in cells.
+	Width, Height int
+	// Ramp lists the glyphs from darkest to densest. It defaults to
+	// DefaultRamp.
+	Ramp []rune
+}
+
+// Stream captures from a webcam and converts the images to frames.
+type Stream struct {
+	mu     sync.Mutex
+	webcam *gocv.VideoCapture
+	width  int
+	height int
+	ramp   []rune
+	err    error
+}
+
+// Open opens the capture device described by opts.
+func Open(opts Options) (*Stream, error) {
+	if opts.Width <= 0 || opts.Height <= 0 {
+		return nil, errors.New("stream: width and height must be positive")
+	}
+	ramp := opts.Ramp
+	if len(ramp) == 0 {
+		ramp = DefaultRamp
+	}
+
+	webcam, err := gocv.VideoCaptureDevice(opts.Device)
+	if err != nil {
+		return nil, err
+	}
+	return &Stream{webcam: webcam, width: opts.Width, height: opts.Height, ramp: ramp}, nil
+}
+
+// SetSize changes the size of the following frames, e.g. after the
+// embedding widget was resized.
+func (s *Stream) SetSize(width, height int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.width, s.height = max(width, 1), max(height, 1)
+}
+
+func (s *Stream) size() (int, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.width, s.height
+}
+
+// Frames captures and converts frames until ctx is done or the device
+// keeps failing, then closes the returned channel; Err tells the two
+// apart. A consumer that falls behind only ever receives the newest frame:
+// frames it was too slow for are dropped rather than queued, so capture
+// never blocks on the consumer and latency stays low. Only one Frames call
+// may be active at a time.
+func (s *Stream) Frames(ctx context.Context) <-chan *Frame {
+	frames := make(chan *Frame, 1)
+	s.setErr(nil)
+	go func() {
+		defer close(frames)
+
+		img := gocv.NewMat()
+		defer img.Close()
+		small := gocv.NewMat()
+		defer small.Close()
+
+		failures := 0
+		delay := readRetryDelay
+		for ctx.Err() == nil {
+			if !s.webcam.Read(&img) || img.Empty() {
+				failures++
+				if failures >= maxReadFailures {
+					s.setErr(ErrReadFailed)
+					return
+				}
+				select {
+				case <-time.After(delay):
+				case <-ctx.Done():
+					return
+				}
+				delay = min(delay*2, maxReadRetryDelay)
+				continue
+			}
+			failures, delay = 0, readRetryDelay
+			captured := time.Now()
+
+			width, height := s.size()
+			gocv.Resize(img, &small, image.Point{X: width, Y: height}, 0, 0, gocv.InterpolationArea)
+			src, err := small.ToImage()
+			if err != nil {
+				s.setErr(err)
+				return
+			}
+			f := Convert(src, width, height, s.ramp)
+			f.Time = captured
+
+			// replace a frame the consumer has not picked up yet
+			select {
+			case <-frames:
+			default:
+			}
+			select {
+			case frames <- f:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return frames
+}
+
+// Err returns why the last Frames call stopped before its context was
+// done, or nil.
+func (s *Stream) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+func (s *Stream) setErr(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.err = err
+}
+
+// Close releases the capture device. It must not be called while Frames
+// is running.
+func (s *Stream) Close() error {
+	return s.webcam.Close()
+}
+
+// Convert converts an image to a frame of cols x rows cells, each averaging
+// the block of pixels it covers and picking a glyph of ramp by brightness.
+func Convert(img image.Image, cols, rows int, ramp []rune) *Frame {
+	f := &Frame{Width: cols, Height: rows, Cells: make([]Cell, cols*rows)}
+	if cols == 0 || rows == 0 || len(ramp) == 0 {
+		return f
+	}
+	pixels := NewPixels(img)
+	bw, bh := BlockSize(img.Bounds(), cols, rows)
+
+	for y := 0; y < rows; y++ {
+		for x := 0; x < cols; x++ {
+			var sr, sg, sb uint32
+			for py := 0; py < bh; py++ {
+				for px := 0; px < bw; px++ {
+					c := pixels.At(x*bw+px, y*bh+py)
+					sr, sg, sb = sr+uint32(c.R), sg+uint32(c.G), sb+uint32(c.B)
+				}
+			}
+			n := uint32(bw * bh)
+			c := color.RGBA{uint8(sr / n), uint8(sg / n), uint8(sb / n), 255}
+			f.Cells[y*cols+x] = Cell{Rune: RampRune(ramp, float32(Luma(c))/255), Color: c}
+		}
+	}
+	return f
+}
+
+// BlockSize returns the size of the pixel block each of cols x rows cells
+// covers in an image with the given bounds.
+func BlockSize(bounds image.Rectangle, cols, rows int) (int, int) {
+	return max(bounds.Dx()/cols, 1), max(bounds.Dy()/rows, 1)
+}
+
+// RampRune maps a brightness in [0, 1] to a glyph of ramp, darkest first.
+func RampRune(ramp []rune, v float32) rune {
+	return ramp[int(float32(len(ramp)-1)*v)]
+}
+
+// Luma returns the perceived luminance of an 8-bit color in the range
+// [0, 255], in integer arithmetic.
+func Luma(c color.RGBA) uint8 {
+	return uint8((299*uint32(c.R) + 587*uint32(c.G) + 114*uint32(c.B) + 500) / 1000)
+}
+
+// Pixels reads the pixels of an image as 8-bit RGBA. RGBA and grayscale
+// images are read directly, which avoids boxing every pixel in a
+// color.Color.
+type Pixels struct {
+	img    image.Image
+	bounds image.Rectangle
+	rgba   *image.RGBA
+	gray   *image.Gray
+}
+
+// NewPixels returns a reader for img.
+func NewPixels(img image.Image) Pixels {
+	p := Pixels{img: img, bounds: img.Bounds()}
+	p.rgba, _ = img.(*image.RGBA)
+	p.gray, _ = img.(*image.Gray)
+	return p
+}
+
+// At returns the pixel at x, y counted from the top left of the image.
+func (p Pixels) At(x, y int) color.RGBA {
+	x, y = p.bounds.Min.X+x, p.bounds.Min.Y+y
+	switch {
+	case p.rgba != nil:
+		return p.rgba.RGBAAt(x, y)
+	case p.gray != nil:
+		v := p.gray.GrayAt(x, y).Y
+		return color.RGBA{v, v, v, 255}
+	}
+	r, g, b, a := p.img.At(x, y).RGBA()
+	// RGBA() returns 16-bit channels
+	return color.RGBA{uint8(r >> 8), uint8(g >> 8), uint8(b >> 8), uint8(a >> 8)}
+}
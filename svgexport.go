@@ -0,0 +1,103 @@
+//go:build !minimal
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+	"time"
+)
+
+func init() {
+	screenshotFormats["svg"] = writeSVGScreenshot
+}
+
+// writeSVGScreenshot writes a screenshot as an SVG image.
+func writeSVGScreenshot(w io.Writer, sf *shownFrame) (string, error) {
+	return ".svg", writeSVG(w, sf)
+}
+
+const (
+	// svgCellWidth and svgCellHeight are the size of one cell in SVG
+	// frames.
+	svgCellWidth  = 10
+	svgCellHeight = 20
+	// svgFontSize and svgBaseline place glyphs within the cells.
+	svgFontSize = 16
+	svgBaseline = 15
+)
+
+// writeSVG writes a frame as an SVG image: a rectangle for every run of
+// cells with the same background and a text element for every run with
+// the same foreground. Each text is stretched to the width of its cells,
+// so the grid stays aligned whichever monospace font draws it.
+func writeSVG(w io.Writer, sf *shownFrame) error {
+	bw := bufio.NewWriter(w)
+	width, height := sf.width*svgCellWidth, sf.height*svgCellHeight
+	fmt.Fprintf(bw, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%d\" height=\"%d\" viewBox=\"0 0 %d %d\">\n", width, height, width, height)
+	fmt.Fprintf(bw, "<desc>%s %s</desc>\n", html.EscapeString(sf.meta.source), sf.meta.captured.Format(time.RFC3339Nano))
+	fmt.Fprintf(bw, "<rect width=\"100%%\" height=\"100%%\" fill=\"%s\"/>\n", hexColor(exportBackground))
+
+	for y := 0; y < sf.height; y++ {
+		for x := 0; x < sf.width; {
+			c := sf.at(x, y)
+			run := x + 1
+			for run < sf.width && sf.at(run, y).bg == c.bg {
+				run++
+			}
+			if c.bg.A > 0 {
+				fmt.Fprintf(bw, "<rect x=\"%d\" y=\"%d\" width=\"%d\" height=\"%d\" fill=\"%s\"/>\n",
+					x*svgCellWidth, y*svgCellHeight, (run-x)*svgCellWidth, svgCellHeight, hexColor(c.bg))
+			}
+			x = run
+		}
+	}
+
+	fmt.Fprintf(bw, "<g font-family=\"monospace\" font-size=\"%d\" xml:space=\"preserve\">\n", svgFontSize)
+	for y := 0; y < sf.height; y++ {
+		for x := 0; x < sf.width; {
+			c := sf.at(x, y)
+			run := x + 1
+			for run < sf.width && sf.at(run, y).fg == c.fg {
+				run++
+			}
+			var sb strings.Builder
+			for i := x; i < run; i++ {
+				sb.WriteRune(sf.at(i, y).r)
+			}
+			if text := sb.String(); strings.TrimSpace(text) != "" {
+				fg := exportForeground
+				if c.fg.A > 0 {
+					fg = c.fg
+				}
+				fmt.Fprintf(bw, "<text x=\"%d\" y=\"%d\" textLength=\"%d\" lengthAdjust=\"spacingAndGlyphs\" fill=\"%s\">%s</text>\n",
+					x*svgCellWidth, y*svgCellHeight+svgBaseline, (run-x)*svgCellWidth, hexColor(fg), html.EscapeString(text))
+			}
+			x = run
+		}
+	}
+	bw.WriteString("</g>\n</svg>\n")
+	return bw.Flush()
+}
+
+// svgWriter exports a single frame as an SVG image.
+type svgWriter struct {
+	w      io.Writer
+	color  bool
+	frames int
+}
+
+func (sw *svgWriter) writeFrame(f *frame, at time.Duration) error {
+	if sw.frames > 0 {
+		return fmt.Errorf("an SVG holds a single frame, export videos as .cast")
+	}
+	sw.frames++
+	return writeSVG(sw.w, newShownFrame(f, sw.color))
+}
+
+func (sw *svgWriter) close() error {
+	return nil
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// oscFlavor describes which desktop notification escape the terminal understands.
+type oscFlavor int
+
+const (
+	oscNone oscFlavor = iota
+	osc9
+	osc777
+)
+
+var (
+	notifyFlavor = detectNotifyFlavor()
+
+	// oscOut receives the title, notification and mode escapes.
+	oscOut io.Writer = os.Stdout
+)
+
+// detectNotifyFlavor guesses the notification escape supported by the
+// terminal from the environment, since there is no way to query it.
+func detectNotifyFlavor() oscFlavor {
+	switch os.Getenv("TERM_PROGRAM") {
+	case "iTerm.app", "WezTerm", "ghostty":
+		return osc9
+	}
+	term := os.Getenv("TERM")
+	if strings.Contains(term, "kitty") {
+		return osc9
+	}
+	if os.Getenv("VTE_VERSION") != "" || strings.HasPrefix(term, "rxvt") || strings.HasPrefix(term, "foot") {
+		return osc777
+	}
+	return oscNone
+}
+
+// oscSanitize strips characters that would terminate or corrupt an OSC sequence.
+func oscSanitize(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// setTitle updates the terminal window/tab title.
+func setTitle(title string) {
+	fmt.Fprintf(oscOut, "\x1b]0;%s\x07", oscSanitize(title))
+}
+
+// pushTitle saves the current title on the terminal's title stack so it can
+// be restored with popTitle on exit.
+func pushTitle() {
+	fmt.Fprint(oscOut, "\x1b[22;0t")
+}
+
+// popTitle restores the title saved by pushTitle.
+func popTitle() {
+	fmt.Fprint(oscOut, "\x1b[23;0t")
+}
+
+// notify emits a desktop notification on terminals that support one,
+// so events are visible even when the tab is in the background.
+func notify(title, body string) {
+	switch notifyFlavor {
+	case osc9:
+		fmt.Fprintf(oscOut, "\x1b]9;%s: %s\x07", oscSanitize(title), oscSanitize(body))
+	case osc777:
+		fmt.Fprintf(oscOut, "\x1b]777;notify;%s;%s\x07", strings.ReplaceAll(oscSanitize(title), ";", ","), oscSanitize(body))
+	}
+}
+
+// statusTitle formats the title shown while the viewer is running.
+func statusTitle(device int, fps int, recording bool) string {
+	title := fmt.Sprintf("ascii-webcam - camera %d - %d fps", device, fps)
+	if recording {
+		title += " - REC"
+	}
+	return title
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+)
+
+const thresholdStep = 0.05
+
+var (
+	// thresholdEnabled switches to two-level output: every cell is either
+	// the darkest or the densest glyph of the ramp.
+	thresholdEnabled = false
+	// thresholdLevel is the luminance at and above which a cell is white.
+	thresholdLevel float32 = 0.5
+)
+
+// applyThreshold maps a luminance to 0 or 1 in threshold mode and leaves
+// it untouched otherwise.
+func applyThreshold(v float32) float32 {
+	if !thresholdEnabled {
+		return v
+	}
+	if v >= thresholdLevel {
+		return 1
+	}
+	return 0
+}
+
+// thresholdColor returns pure black or white for a source color in
+// threshold mode, so colored output stays two-level as well.
+func thresholdColor(c color.RGBA) color.RGBA {
+	if applyThreshold(adjustLevels(brightness(c))) == 1 {
+		return color.RGBA{255, 255, 255, 255}
+	}
+	return color.RGBA{0, 0, 0, 255}
+}
+
+// changeThreshold moves the threshold level by steps.
+func changeThreshold(steps int) {
+	thresholdLevel = min(max(thresholdLevel+float32(steps)*thresholdStep, thresholdStep), 1)
+}
+
+func thresholdReadout() string {
+	if !thresholdEnabled {
+		return "Threshold: off"
+	}
+	return fmt.Sprintf("Threshold: %.2f", thresholdLevel)
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"image"
+	"slices"
+	"time"
+)
+
+const (
+	// tiledMinWidth is the terminal width from which frames are converted
+	// in tiles.
+	tiledMinWidth = 300
+	// tileWidth is the width of a tile in cells; tiles span the full height.
+	tileWidth = 32
+	// frameBudget is the time conversion may take per frame in tiled mode.
+	frameBudget = time.Second / 30
+)
+
+// interlaced makes the viewer convert only the even rows of one frame and
+// the odd rows of the next, halving the work per frame on large terminals.
+var interlaced = false
+
+// tiledConverter converts frames too wide to convert at the target frame
+// rate. Each frame it converts as many tiles as fit in the frame budget,
+// continuing round-robin where the previous frame stopped, and keeps the
+// other tiles from earlier frames, so the UI stays responsive. In
+// interlaced mode it converts alternate rows instead.
+type tiledConverter struct {
+	// base holds the converted cells before the whole-frame passes.
+	base *frame
+	lum  []float32
+	next int
+}
+
+// convert converts img into a frame of cols x rows cells, tiled if the
+// frame is wide enough or interlaced if that is enabled.
+func (t *tiledConverter) convert(img image.Image, cols, rows int) *frame {
+	if (cols < tiledMinWidth && !interlaced) || rows == 0 {
+		t.base = nil
+		// the brightness buffer is reused from frame to frame
+		t.lum = slices.Grow(t.lum[:0], cols*rows)[:cols*rows]
+		f := newFrame(cols, rows)
+		convertColumns(img, f, t.lum, 0, cols)
+		finishFrame(img, f, t.lum)
+		return f
+	}
+
+	if t.base == nil || t.base.width != cols || t.base.height != rows {
+		t.base = newFrame(cols, rows)
+		t.lum = make([]float32, cols*rows)
+		t.next = 0
+		convertColumns(img, t.base, t.lum, 0, cols)
+	} else if interlaced {
+		t.next = 1 - t.next%2
+		converters.convert(img, t.base, t.lum, 0, cols, t.next, 2)
+	} else {
+		start := time.Now()
+		tiles := (cols + tileWidth - 1) / tileWidth
+		for n := 0; n < tiles && time.Since(start) < frameBudget; n++ {
+			x0 := t.next * tileWidth
+			convertColumns(img, t.base, t.lum, x0, min(x0+tileWidth, cols))
+			t.next = (t.next + 1) % tiles
+		}
+	}
+
+	// frames are shared once converted, so the passes work on a copy
+	f := &frame{width: cols, height: rows, cells: slices.Clone(t.base.cells)}
+	finishFrame(img, f, t.lum)
+	return f
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+)
+
+// tintMode selects a single-hue theme that draws the ramp like an old
+// monochrome CRT.
+type tintMode int
+
+const (
+	tintNone tintMode = iota
+	tintAmber
+	tintGreen
+	tintCyan
+)
+
+var tintNames = []string{"none", "amber", "green", "cyan"}
+
+// tintColors are the phosphor colors at full brightness.
+var tintColors = []color.RGBA{
+	tintAmber: {255, 176, 0, 255},
+	tintGreen: {51, 255, 51, 255},
+	tintCyan:  {0, 230, 255, 255},
+}
+
+// tintFloor is the intensity of the darkest tinted glyphs, so they stay
+// visible like the glow of a phosphor screen.
+const tintFloor = 0.3
+
+func (t tintMode) String() string {
+	return tintNames[t]
+}
+
+// Set implements flag.Value.
+func (t *tintMode) Set(name string) error {
+	for i, n := range tintNames {
+		if n == name {
+			*t = tintMode(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown tint %q", name)
+}
+
+// next returns the tint following t, wrapping around.
+func (t tintMode) next() tintMode {
+	return (t + 1) % tintMode(len(tintNames))
+}
+
+// apply replaces a color with the tint hue at the color's brightness.
+func (t tintMode) apply(c color.RGBA) color.RGBA {
+	base := tintColors[t]
+	v := tintFloor + (1-tintFloor)*brightness(c)
+	return color.RGBA{clampChannel(float32(base.R) * v), clampChannel(float32(base.G) * v), clampChannel(float32(base.B) * v), 255}
+}
@@ -0,0 +1,260 @@
+//go:build !minimal
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+func init() {
+	subcommands["trim"] = subcommand{run: runTrim, summary: "cut a recording to a time range", failure: "Error trimming"}
+}
+
+// errStopReading ends readRecording early without it being an error.
+var errStopReading = errors.New("stop reading")
+
+// runTrim implements the trim subcommand, which cuts a .cast or JSON Lines
+// recording to a time range and writes it in any format convert can.
+func runTrim(args []string) error {
+	fs := flag.NewFlagSet("trim", flag.ExitOnError)
+	out := fs.String("out", "", "output file (.cast, .gif, .mp4, .html, .txt or .json)")
+	from := fs.Duration("from", 0, "start of the range to keep")
+	to := fs.Duration("to", 0, "end of the range to keep (default: end of recording)")
+	color := fs.Bool("color", true, "keep the recording's colors")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s trim [flags] input.cast|input.json --out out.cast|out.gif|out.mp4\n", filepath.Base(os.Args[0]))
+		fs.PrintDefaults()
+	}
+
+	inputs, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(inputs) != 1 || *out == "" {
+		fs.Usage()
+		os.Exit(2)
+	}
+	if *to != 0 && *to <= *from {
+		return fmt.Errorf("--to must be after --from")
+	}
+	if samePath(inputs[0], *out) {
+		return fmt.Errorf("%v would overwrite its input", *out)
+	}
+
+	// cast to cast copies the events themselves, which keeps them exact
+	if strings.ToLower(filepath.Ext(inputs[0])) == ".cast" && strings.ToLower(filepath.Ext(*out)) == ".cast" {
+		return trimCast(inputs[0], *out, *from, *to)
+	}
+	return trimRecording(inputs[0], *out, *from, *to, *color)
+}
+
+// trimRecording writes the frames of a recording between from and to
+// through the exporter matching the output's extension, shifting their
+// times to start at zero. What was on screen at from opens the output.
+func trimRecording(input, output string, from, to time.Duration, color bool) error {
+	w, file, err := createFrameWriter(output, color)
+	if err != nil {
+		return err
+	}
+	defer discardOutput(file)
+
+	var before *frame
+	kept := 0
+	err = readRecording(input, func(f *frame, at time.Duration) error {
+		if at < from {
+			before = f
+			return nil
+		}
+		if to != 0 && at > to {
+			return errStopReading
+		}
+		if kept == 0 && before != nil && at > from {
+			if err := w.writeFrame(before, 0); err != nil {
+				return err
+			}
+			kept++
+		}
+		kept++
+		return w.writeFrame(f, at-from)
+	})
+	if err != nil && err != errStopReading {
+		return err
+	}
+	if kept == 0 {
+		return fmt.Errorf("no frames between %v and %v", from, to)
+	}
+
+	if err := w.close(); err != nil {
+		return err
+	}
+	return finishOutput(file, output)
+}
+
+// readRecording calls fn with every frame of a .cast or JSON Lines
+// recording and its time from the start, until fn returns an error.
+func readRecording(path string, fn func(f *frame, at time.Duration) error) error {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".cast":
+		return readCastFrames(path, fn)
+	case ".json", ".jsonl":
+		return readJSONFrames(path, fn)
+	}
+	return fmt.Errorf("unsupported recording format %q, expected .cast or .json", filepath.Ext(path))
+}
+
+// readCastFrames replays a cast file, passing the screen after each event.
+func readCastFrames(path string, fn func(f *frame, at time.Duration) error) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 1<<20), 64<<20)
+	if !scanner.Scan() {
+		return fmt.Errorf("%v is empty", path)
+	}
+	header, err := parseCastHeader(path, scanner.Bytes())
+	if err != nil {
+		return err
+	}
+
+	screen := newANSIScreen(header.Width, header.Height)
+	for scanner.Scan() {
+		at, kind, data, err := parseCastEvent(path, scanner.Bytes())
+		if err != nil {
+			return err
+		}
+		if kind != "o" {
+			continue
+		}
+		screen.write(data)
+		f := newFrame(screen.f.width, screen.f.height)
+		copy(f.cells, screen.f.cells)
+		if err := fn(f, at); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// readJSONFrames reads frames exported as JSON Lines, timing them by when
+// they were captured.
+func readJSONFrames(path string, fn func(f *frame, at time.Duration) error) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var start time.Time
+	decoder := json.NewDecoder(bufio.NewReader(file))
+	for n := 0; ; n++ {
+		var jf jsonFrame
+		if err := decoder.Decode(&jf); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("malformed frame in %v: %v", path, err)
+		}
+		if n == 0 {
+			start = jf.Captured
+		}
+
+		f := newFrame(jf.Width, jf.Height)
+		f.meta = frameMeta{captured: jf.Captured, source: jf.Source, number: jf.Number, scene: jf.Scene}
+		for y, row := range jf.Rows {
+			for x, jc := range row {
+				if y >= f.height || x >= f.width {
+					continue
+				}
+				c := cell{r: ' '}
+				if r := []rune(jc.Rune); len(r) > 0 {
+					c.r = r[0]
+				}
+				if jc.FG != "" {
+					c.color, _ = parseHexColor(jc.FG)
+				}
+				f.cells[y*f.width+x] = c
+			}
+		}
+		if err := fn(f, jf.Captured.Sub(start)); err != nil {
+			return err
+		}
+	}
+}
+
+// trimCast copies the events of a cast file between from and to into a
+// new file, shifting their timestamps to start at zero.
+func trimCast(input, output string, from, to time.Duration) error {
+	in, err := os.Open(input)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	outFile, err := os.Create(output)
+	if err != nil {
+		return err
+	}
+	defer outFile.Close()
+	w := bufio.NewWriter(outFile)
+
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 0, 1<<20), 64<<20)
+	if !scanner.Scan() {
+		return fmt.Errorf("%v is empty", input)
+	}
+	// the header is copied as is
+	w.Write(scanner.Bytes())
+	w.WriteByte('\n')
+
+	kept := 0
+	for scanner.Scan() {
+		var ev []interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &ev); err != nil || len(ev) < 3 {
+			return fmt.Errorf("malformed event in %v", input)
+		}
+		seconds, ok := ev[0].(float64)
+		if !ok {
+			return fmt.Errorf("malformed event time in %v", input)
+		}
+
+		at := time.Duration(seconds * float64(time.Second))
+		if at < from {
+			continue
+		}
+		if to != 0 && at > to {
+			break
+		}
+
+		ev[0] = (at - from).Seconds()
+		line, err := json.Marshal(ev)
+		if err != nil {
+			return err
+		}
+		w.Write(line)
+		w.WriteByte('\n')
+		kept++
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if kept == 0 {
+		return fmt.Errorf("no frames between %v and %v", from, to)
+	}
+
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	return outFile.Close()
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/gdamore/tcell"
+)
+
+// tutorialStep is one page of the tutorial. It advances once the user
+// triggers one of the listed events; an empty list accepts any key. The
+// text names the keys bound to the events, in order, with %v verbs.
+type tutorialStep struct {
+	text   string
+	events []event
+}
+
+var tutorialSteps = []tutorialStep{
+	{"Welcome to ascii-webcam! Press '%v' to toggle color.", []event{colorToggle}},
+	{"Press '%v'/'%v' to adjust brightness and '%v'/'%v' to adjust contrast.", []event{increaseBrightness, decreaseBrightness, increaseContrast, decreaseContrast}},
+	{"Press '%v' to cycle charsets, the glyphs frames are drawn with.", []event{charsetCycle}},
+	{"Press '%v' for pixel mode, which draws blocks of color instead of glyphs.", []event{pixelToggle}},
+	{"Press '%v' to outline strong edges with line glyphs, or '%v' to match glyph shapes.", []event{edgesToggle, glyphToggle}},
+	{"Press '%v' to cycle dithering modes for smoother gradients.", []event{ditherCycle}},
+	{"Press '%v'/'%v' to zoom in and out.", []event{zoomIn, zoomOut}},
+	{"Press '%v' to save a screenshot of the current frame in the -screenshot-format.", []event{screenshot}},
+	{"Press '%v' to start recording to a .cast file, and again to stop and save it.", []event{recordToggle}},
+	{"Press '%v' to list every key, and again to close the list.", []event{helpToggle}},
+	{"That's it! Type :tutorial to see this again. Press any key to close.", nil},
+}
+
+// render returns the text of the step with the keys filled in.
+func (s tutorialStep) render() string {
+	keys := make([]any, len(s.events))
+	for i, ev := range s.events {
+		keys[i] = keyFor(ev)
+	}
+	return fmt.Sprintf(s.text, keys...)
+}
+
+// tutorial is the guided overlay walking through the main features.
+type tutorial struct {
+	step   int
+	active bool
+}
+
+func (t *tutorial) start() {
+	t.step = 0
+	t.active = true
+}
+
+// handle advances the tutorial if ev completes the current step.
+func (t *tutorial) handle(ev event) {
+	if !t.active {
+		return
+	}
+	switch ev {
+	case resize, focusIn, focusOut:
+		return
+	}
+
+	step := tutorialSteps[t.step]
+	done := len(step.events) == 0
+	for _, e := range step.events {
+		done = done || e == ev
+	}
+	if !done {
+		return
+	}
+
+	t.step++
+	if t.step >= len(tutorialSteps) {
+		t.active = false
+	}
+}
+
+// draw puts the current step on a translucent bar along the top of the
+// screen, or removes the bar once the tutorial is over.
+func (t *tutorial) draw(s tcell.Screen) {
+	if !t.active {
+		overlays.remove("tutorial")
+		return
+	}
+
+	width, _ := s.Size()
+	bar := overlays.surface("tutorial", tutorialLayer)
+	bar.clear()
+	style := tcell.StyleDefault.Background(palette.highlight).Foreground(tcell.ColorBlack)
+	text := " " + tutorialSteps[t.step].render()
+	for x := len([]rune(text)); x < width; x++ {
+		bar.set(x, 0, overlayCell{r: ' ', style: style, alpha: 0.6})
+	}
+	bar.text(0, 0, text, style, 1)
+}
+
+// tutorialMarker is the file recording that the tutorial was already shown.
+func tutorialMarker() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "ascii-webcam", "tutorial-seen"), nil
+}
+
+// firstRun reports whether the tutorial has never been shown, and marks it
+// as shown.
+func firstRun() bool {
+	marker, err := tutorialMarker()
+	if err != nil {
+		return false
+	}
+	if _, err := os.Stat(marker); err == nil {
+		return false
+	}
+	if err := os.MkdirAll(filepath.Dir(marker), 0o755); err != nil {
+		return false
+	}
+	if err := os.WriteFile(marker, nil, 0o644); err != nil {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,68 @@
+//go:build !minimal
+
+package main
+
+import (
+	"os"
+	"time"
+
+	"gocv.io/x/gocv"
+)
+
+const (
+	// videoExportFPS is the frame rate of exported videos. Frames arriving
+	// at other times are repeated or dropped to fit it.
+	videoExportFPS = 30
+	videoCodec     = "mp4v"
+)
+
+// videoWriter exports frames as an MP4 video. OpenCV writes the file by
+// name, so the file created for it is only used for its path.
+type videoWriter struct {
+	file    *os.File
+	color   bool
+	video   *gocv.VideoWriter
+	pending gocv.Mat
+	written int
+}
+
+func (vw *videoWriter) writeFrame(f *frame, at time.Duration) error {
+	img := rasterizeMat(newShownFrame(f, vw.color))
+	if vw.video == nil {
+		video, err := gocv.VideoWriterFile(vw.file.Name(), videoCodec, videoExportFPS, img.Cols(), img.Rows(), true)
+		if err != nil {
+			img.Close()
+			return err
+		}
+		vw.video = video
+	} else {
+		// the previous frame is shown until this one's time
+		due := int(at.Seconds() * videoExportFPS)
+		for vw.written < due {
+			if err := vw.write(vw.pending); err != nil {
+				img.Close()
+				return err
+			}
+		}
+		vw.pending.Close()
+	}
+	vw.pending = img
+	return nil
+}
+
+func (vw *videoWriter) write(img gocv.Mat) error {
+	vw.written++
+	return vw.video.Write(img)
+}
+
+func (vw *videoWriter) close() error {
+	if vw.video == nil {
+		return nil
+	}
+	defer vw.pending.Close()
+	if err := vw.write(vw.pending); err != nil {
+		vw.video.Close()
+		return err
+	}
+	return vw.video.Close()
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/gdamore/tcell"
+)
+
+// drawWarmup shows a centered indicator while the capture device is
+// settling its exposure and white balance, with progress in [0, 1].
+func drawWarmup(s tcell.Screen, progress float32) {
+	width, height := s.Size()
+	text := fmt.Sprintf(" Warming up camera %d%% ", int(progress*100))
+
+	sf := overlays.surface("warmup", warmupLayer)
+	sf.clear()
+	sf.text(max((width-len(text))/2, 0), (height-logHeight)/2, text, tcell.StyleDefault.Foreground(palette.message), 1)
+}
+
+// clearWarmup removes the warm-up indicator.
+func clearWarmup() {
+	overlays.remove("warmup")
+}
@@ -0,0 +1,146 @@
+//go:build !minimal
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+func init() {
+	subcommands["watch"] = subcommand{run: runWatch, summary: "convert every image or video dropped into a directory", failure: "Error watching"}
+}
+
+// isVideoFile reports whether path looks like a video the converter can read.
+func isVideoFile(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".mp4", ".mov", ".avi", ".mkv", ".webm", ".m4v", ".gif":
+		return true
+	}
+	return false
+}
+
+// watchedFile tracks a file seen in the watch directory until its size
+// stops changing, so files still being copied are not converted early.
+type watchedFile struct {
+	size    int64
+	modTime time.Time
+	done    bool
+}
+
+// absPath returns the absolute form of path, or path itself if it has none.
+func absPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
+
+// runWatch implements the watch subcommand, which converts every image or
+// video dropped into a directory.
+func runWatch(args []string) error {
+	fs := flag.NewFlagSet("watch", flag.ExitOnError)
+	outDir := fs.String("out-dir", "", "directory for converted files (default: the watched directory)")
+	format := fs.String("format", "txt", "output format: txt, cast, html, json, png, svg, gif or mp4 (png and svg for images only)")
+	width := fs.Int("width", 80, "output width in characters")
+	height := fs.Int("height", 0, "output height in characters (default: keep aspect ratio)")
+	color := fs.Bool("color", false, "include colors in .cast, .html, .png, .svg, .json, .gif and .mp4 output")
+	interval := fs.Duration("interval", time.Second, "how often to scan the directory")
+	existing := fs.Bool("existing", false, "also convert files already present at startup")
+	fs.BoolVar(&edgesEnabled, "edges", false, "draw strong edges with orientation-matched glyphs")
+	fs.BoolVar(&glyphEnabled, "glyphs", false, "pick glyphs by matching their shape against the image")
+	fs.Var(&dither, "dither", "dithering: none, bayer or fs")
+	fs.IntVar(&supersample, "supersample", 1, "average NxN source pixels per cell to reduce shimmering, up to 4 (1 disables)")
+	interp := interpolationLinear
+	fs.Var(&interp, "interpolation", "resize interpolation: nearest, linear, area, cubic or lanczos")
+	fs.Var(&deinterlacing, "deinterlace", "deinterlace the input: none, blend or bob")
+	fs.BoolVar(&invertEnabled, "invert", false, "invert the glyph ramp for light backgrounds")
+	fs.BoolVar(&thresholdEnabled, "threshold", false, "render only the darkest and densest glyphs")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s watch [flags] dir\n", filepath.Base(os.Args[0]))
+		fs.PrintDefaults()
+	}
+
+	dirs, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(dirs) != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	interpolation.Store(int32(interp))
+	dir := dirs[0]
+	if *outDir == "" {
+		*outDir = dir
+	}
+	ext := "." + strings.TrimPrefix(*format, ".")
+	if _, err := frameWriterFor(ext, false); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(*outDir, 0o755); err != nil {
+		return err
+	}
+
+	files := map[string]*watchedFile{}
+	// outputs written so far, which may land in the watched directory with
+	// an input extension and must not be converted in turn
+	produced := map[string]bool{}
+	first := true
+	for {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return err
+		}
+
+		for _, entry := range entries {
+			name := entry.Name()
+			// hidden files include the temporary files outputs are written to
+			if entry.IsDir() || strings.HasPrefix(name, ".") || !(isImageFile(name) || isVideoFile(name)) {
+				continue
+			}
+			input := filepath.Join(dir, name)
+			if produced[absPath(input)] {
+				continue
+			}
+			info, err := entry.Info()
+			if err != nil {
+				continue
+			}
+
+			wf, ok := files[name]
+			if !ok {
+				files[name] = &watchedFile{size: info.Size(), modTime: info.ModTime(), done: first && !*existing}
+				continue
+			}
+			if wf.done {
+				continue
+			}
+			if wf.size != info.Size() || !wf.modTime.Equal(info.ModTime()) {
+				wf.size, wf.modTime = info.Size(), info.ModTime()
+				continue
+			}
+
+			wf.done = true
+			output := filepath.Join(*outDir, strings.TrimSuffix(name, filepath.Ext(name))+ext)
+			if samePath(input, output) {
+				log.Printf("Skipping %v, converting it to %v would overwrite it", name, ext)
+				continue
+			}
+			produced[absPath(output)] = true
+			if err := convertFile(input, output, *width, *height, *color); err != nil {
+				log.Printf("Error converting %v: %v", name, err)
+			} else {
+				log.Printf("Converted %v to %v", name, output)
+			}
+		}
+		first = false
+
+		time.Sleep(*interval)
+	}
+}
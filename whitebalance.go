@@ -0,0 +1,41 @@
+package main
+
+import (
+	"gocv.io/x/gocv"
+)
+
+// maxWhiteBalanceGain limits how much a channel may be amplified, so
+// scenes that really are one color are not pushed to gray entirely.
+const maxWhiteBalanceGain = 2.5
+
+// whiteBalanceFilter is a gray-world auto white balance: it assumes the
+// scene averages to gray and scales each channel so its mean matches the
+// mean of all channels, removing the camera's color cast.
+type whiteBalanceFilter struct{}
+
+func (whiteBalanceFilter) name() string { return "white balance" }
+
+func (whiteBalanceFilter) apply(img *gocv.Mat) {
+	if img.Channels() != 3 {
+		return
+	}
+	mean := img.Mean()
+	means := [3]float64{mean.Val1, mean.Val2, mean.Val3}
+	gray := (means[0] + means[1] + means[2]) / 3
+	if gray == 0 {
+		return
+	}
+
+	channels := gocv.Split(*img)
+	defer func() {
+		for _, c := range channels {
+			c.Close()
+		}
+	}()
+	for i, c := range channels {
+		if means[i] > 0 {
+			c.MultiplyFloat(float32(min(gray/means[i], maxWhiteBalanceGain)))
+		}
+	}
+	gocv.Merge(channels, img)
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"math"
+	"sync"
+)
+
+const (
+	// zoomStep is the magnification factor of one zoom step.
+	zoomStep = 1.25
+	maxZoom  = 8
+	// panStep is how far one pan step moves, as a fraction of the visible
+	// region.
+	panStep = 0.1
+)
+
+// viewport is the digital zoom: the magnification and the center of the
+// visible region in normalized frame coordinates. It is adjusted by the UI
+// and read by the capture goroutine, which crops the frame before resizing
+// so the zoomed region uses the full terminal resolution.
+type viewport struct {
+	mu   sync.Mutex
+	zoom float64
+	x, y float64
+}
+
+var view = &viewport{zoom: 1, x: 0.5, y: 0.5}
+
+// changeZoom zooms in by steps, or out for negative steps, and returns the
+// new magnification.
+func (v *viewport) changeZoom(steps int) float64 {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.zoom = min(max(v.zoom*math.Pow(zoomStep, float64(steps)), 1), maxZoom)
+	// snap back to exactly 1 so zooming out fully turns cropping off
+	if v.zoom < 1+1e-9 {
+		v.zoom = 1
+	}
+	v.clampCenter()
+	return v.zoom
+}
+
+// pan moves the visible region by dx, dy steps.
+func (v *viewport) pan(dx, dy int) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.x += float64(dx) * panStep / v.zoom
+	v.y += float64(dy) * panStep / v.zoom
+	v.clampCenter()
+}
+
+// clampCenter keeps the visible region inside the frame. v.mu must be held.
+func (v *viewport) clampCenter() {
+	half := 0.5 / v.zoom
+	v.x = min(max(v.x, half), 1-half)
+	v.y = min(max(v.y, half), 1-half)
+}
+
+// reset zooms all the way out.
+func (v *viewport) reset() {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.zoom, v.x, v.y = 1, 0.5, 0.5
+}
+
+// bounds returns the visible part of the frame in normalized coordinates.
+func (v *viewport) bounds() normRect {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	half := 0.5 / v.zoom
+	return normRect{v.x - half, v.y - half, v.x + half, v.y + half}
+}
+
+// state returns the magnification and the center of the visible region.
+func (v *viewport) state() (zoom, x, y float64) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.zoom, v.x, v.y
+}
+
+// restore sets a state returned by state.
+func (v *viewport) restore(zoom, x, y float64) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.zoom, v.x, v.y = min(max(zoom, 1), maxZoom), x, y
+	v.clampCenter()
+}